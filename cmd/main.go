@@ -7,27 +7,47 @@ import (
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sunnyyssh/designing-software-cw1/internal/config"
+	"github.com/sunnyyssh/designing-software-cw1/internal/infrastructure/metrics"
+	"github.com/sunnyyssh/designing-software-cw1/internal/infrastructure/tracing"
 )
 
-const EnvPGConnString = "PG_CONN_STRING"
-
 func main() {
 	ctx := context.Background()
 
-	connString := os.Getenv(EnvPGConnString)
-	if connString == "" {
-		log.Fatalf("%s env variable is not set", EnvPGConnString)
+	set, err := config.LoadSettings(os.Args[1:])
+	if err != nil {
+		log.Fatalf("Loading settings failed: %s", err)
+	}
+
+	// A broken tracing setup must not stop e.g. `bankcli config set` fixing it.
+	tr, err := config.NewTracing(ctx, set)
+	if err != nil {
+		log.Printf("Tracing is disabled: %s", err)
+		tr = config.NoTracing()
 	}
 
-	db, err := pgxpool.New(ctx, connString)
+	// The pool connects lazily, so an empty connection string is reported
+	// by the CLI only for commands that actually need the database.
+	poolConf, err := pgxpool.ParseConfig(set.ConnString)
+	if err != nil {
+		log.Fatalf("Connecting DB failed: %s", err)
+	}
+	poolConf.ConnConfig.Tracer = tracing.NewQueryTracer(tr.Provider)
+	db, err := pgxpool.NewWithConfig(ctx, poolConf)
 	if err != nil {
 		log.Fatalf("Connecting DB failed: %s", err)
 	}
 
-	dbConf := config.NewDB(db)
-	svcConf := config.NewServices(dbConf)
+	metricsConf := config.NewMetrics(db, set.Tenant)
+	dbConf := config.NewDB(db, set.Tenant, set.Actor, metricsConf.Instruments)
+	svcConf := config.NewServices(dbConf, set, metricsConf.Instruments)
+	metrics.RegisterBusiness(metricsConf.Registerer, svcConf.StatsService)
 
-	if err = config.CLI(svcConf).Execute(); err != nil {
+	err = config.CLI(svcConf, metricsConf, tr, set).Execute()
+	if shutdownErr := tr.Shutdown(ctx); shutdownErr != nil {
+		log.Printf("Exporting traces failed: %s", shutdownErr)
+	}
+	if err != nil {
 		log.Fatalf("Execution failed: %s", err)
 	}
 }
@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+// Transactor gives every transaction a place for snapshots of entities locked in it,
+// so that an update takes its "before" state from the lock instead of reading the row again.
+type Transactor struct {
+	storage.Transactor
+}
+
+func NewTransactor(next storage.Transactor) *Transactor {
+	return &Transactor{Transactor: next}
+}
+
+// WithinTx joins the snapshots of the outer transaction, like nested transactions join it.
+func (t *Transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(lockedKey{}).(*locked); !ok {
+		ctx = context.WithValue(ctx, lockedKey{}, &locked{snapshots: make(map[lockedID]any)})
+	}
+	return t.Transactor.WithinTx(ctx, fn)
+}
+
+type lockedKey struct{}
+
+type lockedID struct {
+	entityType domain.EntityType
+	id         uuid.UUID
+}
+
+// locked holds the last known state of entities locked in the transaction.
+type locked struct {
+	mu        sync.Mutex
+	snapshots map[lockedID]any
+}
+
+// remember saves the snapshot of a locked or just updated entity, it's a no-op outside Transactor.
+func remember(ctx context.Context, entityType domain.EntityType, id uuid.UUID, snapshot any) {
+	l, ok := ctx.Value(lockedKey{}).(*locked)
+	if !ok {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.snapshots[lockedID{entityType, id}] = snapshot
+}
+
+// lockedBefore returns the snapshot saved by remember, or loads it with get if the entity wasn't locked.
+func lockedBefore[T any](ctx context.Context, entityType domain.EntityType, id uuid.UUID, get func() (T, error)) (any, error) {
+	if l, ok := ctx.Value(lockedKey{}).(*locked); ok {
+		l.mu.Lock()
+		snapshot, ok := l.snapshots[lockedID{entityType, id}]
+		l.mu.Unlock()
+		if ok {
+			return snapshot, nil
+		}
+	}
+	return get()
+}
@@ -0,0 +1,64 @@
+// Package audit decorates repositories so that every state change is written to the audit log.
+// Entries are written with the same ctx as the change, so they take part in its transaction.
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/auth"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+type Recorder struct {
+	tx        storage.Transactor
+	auditRepo storage.AuditRepo
+	actor     string
+}
+
+func NewRecorder(tx storage.Transactor, auditRepo storage.AuditRepo, actor string) *Recorder {
+	return &Recorder{
+		tx:        tx,
+		auditRepo: auditRepo,
+		actor:     actor,
+	}
+}
+
+// withinTx runs a change with its audit record in one transaction,
+// so that a change is never saved without a record.
+func withinTx[T any](ctx context.Context, rec *Recorder, fn func(ctx context.Context) (T, error)) (T, error) {
+	var res T
+	err := rec.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		res, err = fn(ctx)
+		return err
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return res, nil
+}
+
+// record stores snapshots of the entity, before or after may be nil. It must be an untyped nil,
+// a nil DTO pointer would be stored as JSON null.
+// Snapshots are DTOs, so that they have stable json field names.
+func (r *Recorder) record(
+	ctx context.Context,
+	action domain.AuditAction,
+	entityType domain.EntityType,
+	entityID uuid.UUID,
+	before, after any,
+) error {
+	actor := r.actor
+	if user := auth.UserFrom(ctx); user != nil {
+		actor = user.Name
+	}
+	entry, err := domain.NewAuditEntry(actor, action, entityType, entityID, before, after)
+	if err != nil {
+		return fmt.Errorf("failed to make audit entry: %w", err)
+	}
+	return r.auditRepo.Create(ctx, entry)
+}
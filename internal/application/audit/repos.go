@@ -0,0 +1,378 @@
+package audit
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+type BankAccountRepo struct {
+	storage.BankAccountRepo
+	rec *Recorder
+}
+
+func NewBankAccountRepo(next storage.BankAccountRepo, rec *Recorder) *BankAccountRepo {
+	return &BankAccountRepo{BankAccountRepo: next, rec: rec}
+}
+
+func (r *BankAccountRepo) Create(ctx context.Context, acc *domain.BankAccount) (*domain.BankAccount, error) {
+	return withinTx(ctx, r.rec, func(ctx context.Context) (*domain.BankAccount, error) {
+		acc, err := r.BankAccountRepo.Create(ctx, acc)
+		if err != nil {
+			return nil, err
+		}
+		return acc, r.rec.record(ctx, domain.AuditActionCreate, domain.EntityBankAccount, acc.ID, nil, dto.NewBankAccountDTO(acc))
+	})
+}
+
+func (r *BankAccountRepo) GetForUpdate(ctx context.Context, id uuid.UUID) (*domain.BankAccount, error) {
+	acc, err := r.BankAccountRepo.GetForUpdate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	remember(ctx, domain.EntityBankAccount, acc.ID, dto.NewBankAccountDTO(acc))
+	return acc, nil
+}
+
+func (r *BankAccountRepo) Update(ctx context.Context, acc *domain.BankAccount) (*domain.BankAccount, error) {
+	return withinTx(ctx, r.rec, func(ctx context.Context) (*domain.BankAccount, error) {
+		before, err := lockedBefore(ctx, domain.EntityBankAccount, acc.ID, func() (*dto.BankAccountDTO, error) {
+			before, err := r.BankAccountRepo.Get(ctx, acc.ID)
+			if err != nil {
+				return nil, err
+			}
+			return dto.NewBankAccountDTO(before), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		acc, err = r.BankAccountRepo.Update(ctx, acc)
+		if err != nil {
+			return nil, err
+		}
+		after := dto.NewBankAccountDTO(acc)
+		remember(ctx, domain.EntityBankAccount, acc.ID, after)
+		return acc, r.rec.record(ctx, domain.AuditActionUpdate, domain.EntityBankAccount, acc.ID, before, after)
+	})
+}
+
+func (r *BankAccountRepo) Delete(ctx context.Context, id uuid.UUID) (*domain.BankAccount, error) {
+	return withinTx(ctx, r.rec, func(ctx context.Context) (*domain.BankAccount, error) {
+		acc, err := r.BankAccountRepo.Delete(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return acc, r.rec.record(ctx, domain.AuditActionDelete, domain.EntityBankAccount, acc.ID, dto.NewBankAccountDTO(acc), nil)
+	})
+}
+
+type CategoryRepo struct {
+	storage.CategoryRepo
+	rec *Recorder
+}
+
+func NewCategoryRepo(next storage.CategoryRepo, rec *Recorder) *CategoryRepo {
+	return &CategoryRepo{CategoryRepo: next, rec: rec}
+}
+
+func (r *CategoryRepo) Create(ctx context.Context, cat *domain.Category) (*domain.Category, error) {
+	return withinTx(ctx, r.rec, func(ctx context.Context) (*domain.Category, error) {
+		cat, err := r.CategoryRepo.Create(ctx, cat)
+		if err != nil {
+			return nil, err
+		}
+		return cat, r.rec.record(ctx, domain.AuditActionCreate, domain.EntityCategory, cat.ID, nil, dto.NewCategoryDTO(cat))
+	})
+}
+
+func (r *CategoryRepo) GetForUpdate(ctx context.Context, id uuid.UUID) (*domain.Category, error) {
+	cat, err := r.CategoryRepo.GetForUpdate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	remember(ctx, domain.EntityCategory, cat.ID, dto.NewCategoryDTO(cat))
+	return cat, nil
+}
+
+func (r *CategoryRepo) Update(ctx context.Context, cat *domain.Category) (*domain.Category, error) {
+	return withinTx(ctx, r.rec, func(ctx context.Context) (*domain.Category, error) {
+		before, err := lockedBefore(ctx, domain.EntityCategory, cat.ID, func() (*dto.CategoryDTO, error) {
+			before, err := r.CategoryRepo.Get(ctx, cat.ID)
+			if err != nil {
+				return nil, err
+			}
+			return dto.NewCategoryDTO(before), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		cat, err = r.CategoryRepo.Update(ctx, cat)
+		if err != nil {
+			return nil, err
+		}
+		after := dto.NewCategoryDTO(cat)
+		remember(ctx, domain.EntityCategory, cat.ID, after)
+		return cat, r.rec.record(ctx, domain.AuditActionUpdate, domain.EntityCategory, cat.ID, before, after)
+	})
+}
+
+func (r *CategoryRepo) Delete(ctx context.Context, id uuid.UUID) (*domain.Category, error) {
+	return withinTx(ctx, r.rec, func(ctx context.Context) (*domain.Category, error) {
+		cat, err := r.CategoryRepo.Delete(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return cat, r.rec.record(ctx, domain.AuditActionDelete, domain.EntityCategory, cat.ID, dto.NewCategoryDTO(cat), nil)
+	})
+}
+
+type OperationRepo struct {
+	storage.OperationRepo
+	rec *Recorder
+}
+
+func NewOperationRepo(next storage.OperationRepo, rec *Recorder) *OperationRepo {
+	return &OperationRepo{OperationRepo: next, rec: rec}
+}
+
+func (r *OperationRepo) Create(ctx context.Context, op *domain.Operation) (*domain.Operation, error) {
+	return withinTx(ctx, r.rec, func(ctx context.Context) (*domain.Operation, error) {
+		op, err := r.OperationRepo.Create(ctx, op)
+		if err != nil {
+			return nil, err
+		}
+		return op, r.rec.record(ctx, domain.AuditActionCreate, domain.EntityOperation, op.ID, nil, dto.NewOperationDTO(op))
+	})
+}
+
+func (r *OperationRepo) GetForUpdate(ctx context.Context, id uuid.UUID) (*domain.Operation, error) {
+	op, err := r.OperationRepo.GetForUpdate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	remember(ctx, domain.EntityOperation, op.ID, dto.NewOperationDTO(op))
+	return op, nil
+}
+
+func (r *OperationRepo) Update(ctx context.Context, op *domain.Operation) (*domain.Operation, error) {
+	return withinTx(ctx, r.rec, func(ctx context.Context) (*domain.Operation, error) {
+		before, err := lockedBefore(ctx, domain.EntityOperation, op.ID, func() (*dto.OperationDTO, error) {
+			before, err := r.OperationRepo.Get(ctx, op.ID)
+			if err != nil {
+				return nil, err
+			}
+			return dto.NewOperationDTO(before), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		op, err = r.OperationRepo.Update(ctx, op)
+		if err != nil {
+			return nil, err
+		}
+		after := dto.NewOperationDTO(op)
+		remember(ctx, domain.EntityOperation, op.ID, after)
+		return op, r.rec.record(ctx, domain.AuditActionUpdate, domain.EntityOperation, op.ID, before, after)
+	})
+}
+
+func (r *OperationRepo) Delete(ctx context.Context, id uuid.UUID) (*domain.Operation, error) {
+	return withinTx(ctx, r.rec, func(ctx context.Context) (*domain.Operation, error) {
+		op, err := r.OperationRepo.Delete(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return op, r.rec.record(ctx, domain.AuditActionDelete, domain.EntityOperation, op.ID, dto.NewOperationDTO(op), nil)
+	})
+}
+
+// reassignment is the snapshot of moving everything from one category to another,
+// it is recorded on the source category.
+type reassignment struct {
+	CategoryID *uuid.UUID `json:"category_id"`
+	Operations int64      `json:"operations,omitempty"`
+	Schedules  int64      `json:"schedules,omitempty"`
+}
+
+func (r *OperationRepo) ReassignCategory(ctx context.Context, from, to uuid.UUID) (int64, error) {
+	return withinTx(ctx, r.rec, func(ctx context.Context) (int64, error) {
+		moved, err := r.OperationRepo.ReassignCategory(ctx, from, to)
+		if err != nil {
+			return 0, err
+		}
+		return moved, r.rec.record(ctx, domain.AuditActionReassign, domain.EntityCategory, from,
+			reassignment{CategoryID: &from}, reassignment{CategoryID: &to, Operations: moved})
+	})
+}
+
+type BudgetRepo struct {
+	storage.BudgetRepo
+	rec *Recorder
+}
+
+func NewBudgetRepo(next storage.BudgetRepo, rec *Recorder) *BudgetRepo {
+	return &BudgetRepo{BudgetRepo: next, rec: rec}
+}
+
+func (r *BudgetRepo) Create(ctx context.Context, budget *domain.Budget) (*domain.Budget, error) {
+	return withinTx(ctx, r.rec, func(ctx context.Context) (*domain.Budget, error) {
+		budget, err := r.BudgetRepo.Create(ctx, budget)
+		if err != nil {
+			return nil, err
+		}
+		return budget, r.rec.record(ctx, domain.AuditActionCreate, domain.EntityBudget, budget.ID, nil, dto.NewBudgetDTO(budget))
+	})
+}
+
+func (r *BudgetRepo) Update(ctx context.Context, budget *domain.Budget) (*domain.Budget, error) {
+	return withinTx(ctx, r.rec, func(ctx context.Context) (*domain.Budget, error) {
+		budgets, err := r.BudgetRepo.ListByCategory(ctx, budget.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		// An untyped nil, so that a missing budget is stored as no snapshot rather than JSON null.
+		var before any
+		for _, b := range budgets {
+			if b.ID == budget.ID {
+				before = dto.NewBudgetDTO(&b)
+			}
+		}
+
+		budget, err = r.BudgetRepo.Update(ctx, budget)
+		if err != nil {
+			return nil, err
+		}
+		return budget, r.rec.record(ctx, domain.AuditActionUpdate, domain.EntityBudget, budget.ID, before, dto.NewBudgetDTO(budget))
+	})
+}
+
+type ScheduleRepo struct {
+	storage.ScheduleRepo
+	rec *Recorder
+}
+
+func NewScheduleRepo(next storage.ScheduleRepo, rec *Recorder) *ScheduleRepo {
+	return &ScheduleRepo{ScheduleRepo: next, rec: rec}
+}
+
+func (r *ScheduleRepo) Create(ctx context.Context, schedule *domain.ScheduledOperation) (*domain.ScheduledOperation, error) {
+	return withinTx(ctx, r.rec, func(ctx context.Context) (*domain.ScheduledOperation, error) {
+		schedule, err := r.ScheduleRepo.Create(ctx, schedule)
+		if err != nil {
+			return nil, err
+		}
+		return schedule, r.rec.record(ctx, domain.AuditActionCreate, domain.EntitySchedule, schedule.ID, nil, dto.NewScheduledOperationDTO(schedule))
+	})
+}
+
+func (r *ScheduleRepo) Delete(ctx context.Context, id uuid.UUID) (*domain.ScheduledOperation, error) {
+	return withinTx(ctx, r.rec, func(ctx context.Context) (*domain.ScheduledOperation, error) {
+		schedule, err := r.ScheduleRepo.Delete(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return schedule, r.rec.record(ctx, domain.AuditActionDelete, domain.EntitySchedule, schedule.ID, dto.NewScheduledOperationDTO(schedule), nil)
+	})
+}
+
+func (r *ScheduleRepo) ReassignCategory(ctx context.Context, from uuid.UUID, to *uuid.UUID) (int64, error) {
+	return withinTx(ctx, r.rec, func(ctx context.Context) (int64, error) {
+		moved, err := r.ScheduleRepo.ReassignCategory(ctx, from, to)
+		if err != nil {
+			return 0, err
+		}
+		return moved, r.rec.record(ctx, domain.AuditActionReassign, domain.EntityCategory, from,
+			reassignment{CategoryID: &from}, reassignment{CategoryID: to, Schedules: moved})
+	})
+}
+
+type UserRepo struct {
+	storage.UserRepo
+	rec *Recorder
+}
+
+func NewUserRepo(next storage.UserRepo, rec *Recorder) *UserRepo {
+	return &UserRepo{UserRepo: next, rec: rec}
+}
+
+func (r *UserRepo) Create(ctx context.Context, user *domain.User) (*domain.User, error) {
+	return withinTx(ctx, r.rec, func(ctx context.Context) (*domain.User, error) {
+		user, err := r.UserRepo.Create(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		return user, r.rec.record(ctx, domain.AuditActionCreate, domain.EntityUser, user.ID, nil, dto.NewUserDTO(user))
+	})
+}
+
+// Update records token rotations too, snapshots leave the token hash out.
+func (r *UserRepo) Update(ctx context.Context, user *domain.User) (*domain.User, error) {
+	return withinTx(ctx, r.rec, func(ctx context.Context) (*domain.User, error) {
+		users, err := r.UserRepo.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var before any
+		for _, u := range users {
+			if u.ID == user.ID {
+				before = dto.NewUserDTO(&u)
+			}
+		}
+
+		user, err = r.UserRepo.Update(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		return user, r.rec.record(ctx, domain.AuditActionUpdate, domain.EntityUser, user.ID, before, dto.NewUserDTO(user))
+	})
+}
+
+// MembershipRepo records changes of members on the account they are members of.
+type MembershipRepo struct {
+	storage.MembershipRepo
+	rec *Recorder
+}
+
+func NewMembershipRepo(next storage.MembershipRepo, rec *Recorder) *MembershipRepo {
+	return &MembershipRepo{MembershipRepo: next, rec: rec}
+}
+
+func (r *MembershipRepo) Save(ctx context.Context, m *domain.Membership) error {
+	_, err := withinTx(ctx, r.rec, func(ctx context.Context) (struct{}, error) {
+		action := domain.AuditActionUpdate
+		var before any
+		prev, err := r.MembershipRepo.Get(ctx, m.AccountID, m.UserID)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			action = domain.AuditActionCreate
+		case err != nil:
+			return struct{}{}, err
+		default:
+			before = dto.NewMembershipDTO(prev)
+		}
+
+		if err := r.MembershipRepo.Save(ctx, m); err != nil {
+			return struct{}{}, err
+		}
+		return struct{}{}, r.rec.record(ctx, action, domain.EntityMember, m.AccountID, before, dto.NewMembershipDTO(m))
+	})
+	return err
+}
+
+func (r *MembershipRepo) Delete(ctx context.Context, accountID, userID uuid.UUID) error {
+	_, err := withinTx(ctx, r.rec, func(ctx context.Context) (struct{}, error) {
+		m, err := r.MembershipRepo.Get(ctx, accountID, userID)
+		if err != nil {
+			return struct{}{}, err
+		}
+		if err := r.MembershipRepo.Delete(ctx, accountID, userID); err != nil {
+			return struct{}{}, err
+		}
+		return struct{}{}, r.rec.record(ctx, domain.AuditActionDelete, domain.EntityMember, accountID, dto.NewMembershipDTO(m), nil)
+	})
+	return err
+}
@@ -0,0 +1,77 @@
+// Package auth carries the current user in ctx and checks its roles on accounts.
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+type userKey struct{}
+
+// WithUser returns ctx carrying the authenticated user.
+func WithUser(ctx context.Context, user *domain.User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFrom returns the user ctx carries, it's nil for unauthenticated calls.
+func UserFrom(ctx context.Context) *domain.User {
+	user, _ := ctx.Value(userKey{}).(*domain.User)
+	return user
+}
+
+// CurrentUser is like UserFrom, but fails with domain.ErrUnauthenticated if there is no user.
+func CurrentUser(ctx context.Context) (*domain.User, error) {
+	user := UserFrom(ctx)
+	if user == nil {
+		return nil, domain.ErrUnauthenticated
+	}
+	return user, nil
+}
+
+type Authorizer struct {
+	memberRepo storage.MembershipRepo
+}
+
+func NewAuthorizer(memberRepo storage.MembershipRepo) *Authorizer {
+	return &Authorizer{memberRepo: memberRepo}
+}
+
+// Require fails with domain.ErrForbidden unless the role of the current user on the account allows need.
+func (a *Authorizer) Require(ctx context.Context, accountID uuid.UUID, need domain.Role) error {
+	user, err := CurrentUser(ctx)
+	if err != nil {
+		return err
+	}
+	m, err := a.memberRepo.Get(ctx, accountID, user.ID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return domain.ErrForbidden
+	}
+	if err != nil {
+		return err
+	}
+	if !m.Role.Allows(need) {
+		return domain.ErrForbidden
+	}
+	return nil
+}
+
+// Roles returns roles of the current user by account, accounts missing in it are not visible.
+func (a *Authorizer) Roles(ctx context.Context) (map[uuid.UUID]domain.Role, error) {
+	user, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	members, err := a.memberRepo.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	roles := make(map[uuid.UUID]domain.Role, len(members))
+	for _, m := range members {
+		roles[m.AccountID] = m.Role
+	}
+	return roles, nil
+}
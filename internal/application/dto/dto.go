@@ -1,6 +1,8 @@
 package dto
 
 import (
+	"encoding/json"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,10 +10,16 @@ import (
 )
 
 type BankAccountDTO struct {
-	ID      uuid.UUID `json:"id"`
-	Name    string    `json:"name"`
-	Balance int64     `json:"balance"`
-	Blocked bool      `json:"blocked"`
+	ID               uuid.UUID  `json:"id"`
+	Name             string     `json:"name"`
+	Type             string     `json:"type"`
+	Currency         string     `json:"currency"`
+	Balance          int64      `json:"balance"`
+	OverdraftLimit   int64      `json:"overdraft_limit"`
+	AvailableToSpend int64      `json:"available_to_spend"`
+	Blocked          bool       `json:"blocked"`
+	ClosedAt         *time.Time `json:"closed_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
 }
 
 func NewBankAccountDTO(dom *domain.BankAccount) *BankAccountDTO {
@@ -19,17 +27,81 @@ func NewBankAccountDTO(dom *domain.BankAccount) *BankAccountDTO {
 		return nil
 	}
 	return &BankAccountDTO{
-		ID:      dom.ID,
-		Name:    dom.Name,
-		Balance: dom.Balance,
-		Blocked: dom.Blocked,
+		ID:               dom.ID,
+		Name:             dom.Name,
+		Type:             string(dom.Type),
+		Currency:         dom.Currency,
+		Balance:          dom.Balance,
+		OverdraftLimit:   dom.OverdraftLimit,
+		AvailableToSpend: dom.AvailableToSpend(),
+		Blocked:          dom.Blocked,
+		ClosedAt:         dom.ClosedAt,
+		CreatedAt:        dom.CreatedAt,
 	}
 }
 
+type UserDTO struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewUserDTO(dom *domain.User) *UserDTO {
+	if dom == nil {
+		return nil
+	}
+	return &UserDTO{
+		ID:        dom.ID,
+		Name:      dom.Name,
+		CreatedAt: dom.CreatedAt,
+	}
+}
+
+type MemberDTO struct {
+	AccountID uuid.UUID `json:"account_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	UserName  string    `json:"user_name"`
+	Role      string    `json:"role"`
+}
+
+type MembershipDTO struct {
+	AccountID uuid.UUID `json:"account_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Role      string    `json:"role"`
+}
+
+func NewMembershipDTO(dom *domain.Membership) *MembershipDTO {
+	if dom == nil {
+		return nil
+	}
+	return &MembershipDTO{
+		AccountID: dom.AccountID,
+		UserID:    dom.UserID,
+		Role:      string(dom.Role),
+	}
+}
+
+type StatsDTO struct {
+	Balances   []CurrencyBalanceDTO `json:"balances"`
+	Operations []OperationCountDTO  `json:"operations"`
+}
+
+type CurrencyBalanceDTO struct {
+	Currency string `json:"currency"`
+	Balance  int64  `json:"balance"`
+}
+
+type OperationCountDTO struct {
+	Type  string `json:"type"`
+	Count int64  `json:"count"`
+}
+
 type CategoryDTO struct {
-	ID   uuid.UUID `json:"id"`
-	Type string    `json:"type"`
-	Name string    `json:"name"`
+	ID        uuid.UUID  `json:"id"`
+	Type      string     `json:"type"`
+	Name      string     `json:"name"`
+	ParentID  *uuid.UUID `json:"parent_id"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 func NewCategoryDTO(category *domain.Category) *CategoryDTO {
@@ -37,10 +109,48 @@ func NewCategoryDTO(category *domain.Category) *CategoryDTO {
 		return nil
 	}
 	return &CategoryDTO{
-		ID:   category.ID,
-		Type: string(category.Type),
-		Name: category.Name,
+		ID:        category.ID,
+		Type:      string(category.Type),
+		Name:      category.Name,
+		ParentID:  category.ParentID,
+		DeletedAt: category.DeletedAt,
+	}
+}
+
+type CategoryTreeDTO struct {
+	ID       uuid.UUID         `json:"id"`
+	Type     string            `json:"type"`
+	Name     string            `json:"name"`
+	Children []CategoryTreeDTO `json:"children,omitempty"`
+}
+
+// NewCategoryTreeDTO builds a forest of all categories, roots sorted by name.
+func NewCategoryTreeDTO(categories []domain.Category) []CategoryTreeDTO {
+	children := make(map[uuid.UUID][]domain.Category)
+	var roots []domain.Category
+	for _, c := range categories {
+		if c.ParentID == nil {
+			roots = append(roots, c)
+			continue
+		}
+		children[*c.ParentID] = append(children[*c.ParentID], c)
+	}
+
+	var build func(cats []domain.Category) []CategoryTreeDTO
+	build = func(cats []domain.Category) []CategoryTreeDTO {
+		sort.Slice(cats, func(i, j int) bool { return cats[i].Name < cats[j].Name })
+		nodes := make([]CategoryTreeDTO, 0, len(cats))
+		for _, c := range cats {
+			nodes = append(nodes, CategoryTreeDTO{
+				ID:       c.ID,
+				Type:     string(c.Type),
+				Name:     c.Name,
+				Children: build(children[c.ID]),
+			})
+		}
+		return nodes
 	}
+	return build(roots)
 }
 
 type OperationDTO struct {
@@ -51,12 +161,23 @@ type OperationDTO struct {
 	Time        time.Time  `json:"time"`
 	Description string     `json:"description"`
 	CategoryID  *uuid.UUID `json:"category_id"`
+	Tags        []string   `json:"tags"`
+	Splits      []SplitDTO `json:"splits,omitempty"`
+}
+
+type SplitDTO struct {
+	CategoryID *uuid.UUID `json:"category_id"`
+	Amount     int64      `json:"amount"`
 }
 
 func NewOperationDTO(operation *domain.Operation) *OperationDTO {
 	if operation == nil {
 		return nil
 	}
+	splits := make([]SplitDTO, 0, len(operation.Splits))
+	for _, s := range operation.Splits {
+		splits = append(splits, SplitDTO{CategoryID: s.CategoryID, Amount: s.Amount})
+	}
 	return &OperationDTO{
 		ID:          operation.ID,
 		AccountID:   operation.AccountID,
@@ -65,5 +186,286 @@ func NewOperationDTO(operation *domain.Operation) *OperationDTO {
 		Time:        operation.Time,
 		Description: operation.Description,
 		CategoryID:  operation.CategoryID,
+		Tags:        append([]string{}, operation.Tags...),
+		Splits:      splits,
+	}
+}
+
+type BudgetDTO struct {
+	ID         uuid.UUID `json:"id"`
+	CategoryID uuid.UUID `json:"category_id"`
+	Period     string    `json:"period"`
+	Limit      int64     `json:"limit"`
+}
+
+func NewBudgetDTO(budget *domain.Budget) *BudgetDTO {
+	if budget == nil {
+		return nil
+	}
+	return &BudgetDTO{
+		ID:         budget.ID,
+		CategoryID: budget.CategoryID,
+		Period:     string(budget.Period),
+		Limit:      budget.Limit,
+	}
+}
+
+type BudgetStatusDTO struct {
+	BudgetDTO
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Spent     int64     `json:"spent"`
+	Remaining int64     `json:"remaining"`
+	Overspent bool      `json:"overspent"`
+}
+
+type ScheduledOperationDTO struct {
+	ID          uuid.UUID  `json:"id"`
+	AccountID   uuid.UUID  `json:"account_id"`
+	Type        string     `json:"type"`
+	Amount      int64      `json:"amount"`
+	Description string     `json:"description"`
+	CategoryID  *uuid.UUID `json:"category_id"`
+	Recurrence  string     `json:"recurrence"`
+	StartAt     time.Time  `json:"start_at"`
+}
+
+func NewScheduledOperationDTO(schedule *domain.ScheduledOperation) *ScheduledOperationDTO {
+	if schedule == nil {
+		return nil
+	}
+	return &ScheduledOperationDTO{
+		ID:          schedule.ID,
+		AccountID:   schedule.AccountID,
+		Type:        string(schedule.Type),
+		Amount:      schedule.Amount,
+		Description: schedule.Description,
+		CategoryID:  schedule.CategoryID,
+		Recurrence:  schedule.Recurrence,
+		StartAt:     schedule.StartAt,
 	}
 }
+
+type ScheduledOccurrenceDTO struct {
+	ScheduleID  uuid.UUID  `json:"schedule_id"`
+	OccursAt    time.Time  `json:"occurs_at"`
+	OperationID *uuid.UUID `json:"operation_id,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	// BudgetExceeded lists budgets the applied operation pushed over the limit.
+	BudgetExceeded []BudgetStatusDTO `json:"budget_exceeded,omitempty"`
+}
+
+type CategoryTotalDTO struct {
+	CategoryID uuid.UUID  `json:"category_id"`
+	Name       string     `json:"name"`
+	Type       string     `json:"type"`
+	ParentID   *uuid.UUID `json:"parent_id"`
+	// Own is the sum of operations in the category itself, Total includes all subcategories.
+	Own   int64 `json:"own"`
+	Total int64 `json:"total"`
+}
+
+type TagTotalDTO struct {
+	Tag        string `json:"tag"`
+	Operations int    `json:"operations"`
+	Income     int64  `json:"income"`
+	Outcome    int64  `json:"outcome"`
+}
+
+type AnomalyDTO struct {
+	Operation OperationDTO       `json:"operation"`
+	Reasons   []AnomalyReasonDTO `json:"reasons"`
+}
+
+type AnomalyReasonDTO struct {
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
+}
+
+func NewAnomalyDTO(anomaly *domain.Anomaly) *AnomalyDTO {
+	if anomaly == nil {
+		return nil
+	}
+	reasons := make([]AnomalyReasonDTO, 0, len(anomaly.Reasons))
+	for _, r := range anomaly.Reasons {
+		reasons = append(reasons, AnomalyReasonDTO{Kind: string(r.Kind), Detail: r.Detail})
+	}
+	return &AnomalyDTO{
+		Operation: *NewOperationDTO(&anomaly.Operation),
+		Reasons:   reasons,
+	}
+}
+
+type AuditEntryDTO struct {
+	ID         uuid.UUID       `json:"id"`
+	Time       time.Time       `json:"time"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   uuid.UUID       `json:"entity_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+}
+
+func NewAuditEntryDTO(entry *domain.AuditEntry) *AuditEntryDTO {
+	if entry == nil {
+		return nil
+	}
+	return &AuditEntryDTO{
+		ID:         entry.ID,
+		Time:       entry.Time,
+		Actor:      entry.Actor,
+		Action:     string(entry.Action),
+		EntityType: string(entry.EntityType),
+		EntityID:   entry.EntityID,
+		Before:     entry.Before,
+		After:      entry.After,
+	}
+}
+
+type AccountHistoryDTO struct {
+	At      time.Time      `json:"at"`
+	Account BankAccountDTO `json:"account"`
+	// Deleted is true if the account had been deleted by then, Account is its last state.
+	Deleted bool `json:"deleted"`
+	Events  int  `json:"events"`
+}
+
+type AccountEventDTO struct {
+	ID             uuid.UUID  `json:"id"`
+	AccountID      uuid.UUID  `json:"account_id"`
+	Type           string     `json:"type"`
+	Time           time.Time  `json:"time"`
+	Name           string     `json:"name,omitempty"`
+	AccountType    string     `json:"account_type,omitempty"`
+	Currency       string     `json:"currency,omitempty"`
+	Balance        int64      `json:"balance,omitempty"`
+	OverdraftLimit int64      `json:"overdraft_limit,omitempty"`
+	OperationID    *uuid.UUID `json:"operation_id,omitempty"`
+	OperationType  string     `json:"operation_type,omitempty"`
+	Amount         int64      `json:"amount,omitempty"`
+}
+
+func NewAccountEventDTO(event *domain.AccountEvent) *AccountEventDTO {
+	if event == nil {
+		return nil
+	}
+	e := &AccountEventDTO{
+		ID:             event.ID,
+		AccountID:      event.AccountID,
+		Type:           string(event.Type),
+		Time:           event.Time,
+		Name:           event.Data.Name,
+		AccountType:    string(event.Data.AccountType),
+		Currency:       event.Data.Currency,
+		Balance:        event.Data.Balance,
+		OverdraftLimit: event.Data.OverdraftLimit,
+		OperationType:  string(event.Data.OperationType),
+		Amount:         event.Data.Amount,
+	}
+	if event.Data.OperationID != uuid.Nil {
+		e.OperationID = &event.Data.OperationID
+	}
+	return e
+}
+
+type StatementDTO struct {
+	Account BankAccountDTO `json:"account"`
+	// From is inclusive, To is exclusive.
+	From           time.Time          `json:"from"`
+	To             time.Time          `json:"to"`
+	OpeningBalance int64              `json:"opening_balance"`
+	TotalIncome    int64              `json:"total_income"`
+	TotalOutcome   int64              `json:"total_outcome"`
+	ClosingBalance int64              `json:"closing_balance"`
+	Lines          []StatementLineDTO `json:"lines"`
+}
+
+type StatementLineDTO struct {
+	OperationID uuid.UUID `json:"operation_id"`
+	Time        time.Time `json:"time"`
+	Type        string    `json:"type"`
+	Description string    `json:"description"`
+	Category    string    `json:"category"`
+	// Amount is negative for outcomes, Balance is the balance right after the operation.
+	Amount  int64 `json:"amount"`
+	Balance int64 `json:"balance"`
+}
+
+type ForecastDTO struct {
+	AccountID uuid.UUID `json:"account_id"`
+	// From is the start of the first projected day.
+	From         time.Time `json:"from"`
+	Days         int       `json:"days"`
+	StartBalance int64     `json:"start_balance"`
+	// LookbackDays of past operations not produced by schedules give the daily averages.
+	LookbackDays     int                  `json:"lookback_days"`
+	CategoryAverages []CategoryAverageDTO `json:"category_averages"`
+	Points           []ForecastPointDTO   `json:"points"`
+	// FirstNegative is the first day the balance is projected to be below zero, nil if it never is.
+	FirstNegative *time.Time `json:"first_negative"`
+}
+
+type CategoryAverageDTO struct {
+	// CategoryID is nil for uncategorized operations.
+	CategoryID *uuid.UUID `json:"category_id"`
+	Name       string     `json:"name"`
+	// Daily is the average balance change per day, negative for spending.
+	Daily float64 `json:"daily"`
+}
+
+type ForecastPointDTO struct {
+	Date time.Time `json:"date"`
+	// Scheduled and Estimated are balance changes of the day from schedules and from averages.
+	Scheduled int64 `json:"scheduled"`
+	Estimated int64 `json:"estimated"`
+	Balance   int64 `json:"balance"`
+}
+
+type DuplicatePairDTO struct {
+	Original  OperationDTO `json:"original"`
+	Duplicate OperationDTO `json:"duplicate"`
+}
+
+type TransferDTO struct {
+	FromAccountID   uuid.UUID `json:"from_account_id"`
+	ToAccountID     uuid.UUID `json:"to_account_id"`
+	FromOperationID uuid.UUID `json:"from_operation_id"`
+	ToOperationID   uuid.UUID `json:"to_operation_id"`
+	Amount          int64     `json:"amount"`
+	Currency        string    `json:"currency"`
+}
+
+type OutboxMessageDTO struct {
+	ID            uuid.UUID       `json:"id"`
+	Type          string          `json:"type"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	LastError     string          `json:"last_error,omitempty"`
+}
+
+func NewOutboxMessageDTO(msg *domain.OutboxMessage) *OutboxMessageDTO {
+	if msg == nil {
+		return nil
+	}
+	return &OutboxMessageDTO{
+		ID:            msg.ID,
+		Type:          msg.Type,
+		Payload:       msg.Payload,
+		CreatedAt:     msg.CreatedAt,
+		Status:        string(msg.Status),
+		Attempts:      msg.Attempts,
+		NextAttemptAt: msg.NextAttemptAt,
+		LastError:     msg.LastError,
+	}
+}
+
+type ChangeDTO struct {
+	Kind      string          `json:"kind"`
+	AccountID uuid.UUID       `json:"account_id"`
+	Operation *OperationDTO   `json:"operation,omitempty"`
+	Account   *BankAccountDTO `json:"account,omitempty"`
+}
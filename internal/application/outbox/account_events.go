@@ -0,0 +1,54 @@
+// Package outbox publishes changes to the outbox table in the transaction that makes them.
+package outbox
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+// Message types of account events as seen by webhook receivers.
+var accountEventTypes = map[domain.AccountEventType]string{
+	domain.EventAccountCreated:    "account.created",
+	domain.EventAccountBlocked:    "account.blocked",
+	domain.EventAccountUnblocked:  "account.unblocked",
+	domain.EventOverdraftLimitSet: "account.overdraft_limit_set",
+	domain.EventOperationApplied:  "operation.applied",
+	domain.EventAccountDeleted:    "account.deleted",
+	domain.EventAccountRestored:   "account.restored",
+}
+
+// AccountEventRepo puts every appended account event into the outbox as well.
+// Services append events within their transactions, so messages share them.
+type AccountEventRepo struct {
+	storage.AccountEventRepo
+	outboxRepo storage.OutboxRepo
+}
+
+func NewAccountEventRepo(next storage.AccountEventRepo, outboxRepo storage.OutboxRepo) *AccountEventRepo {
+	return &AccountEventRepo{AccountEventRepo: next, outboxRepo: outboxRepo}
+}
+
+func (r *AccountEventRepo) Append(ctx context.Context, events ...domain.AccountEvent) error {
+	if err := r.AccountEventRepo.Append(ctx, events...); err != nil {
+		return err
+	}
+
+	for _, e := range events {
+		typ, ok := accountEventTypes[e.Type]
+		if !ok {
+			return fmt.Errorf("%w %q", domain.ErrUnknownAccountEvent, e.Type)
+		}
+		msg, err := domain.NewOutboxMessage(typ, dto.NewAccountEventDTO(&e))
+		if err != nil {
+			return fmt.Errorf("failed to make outbox message: %w", err)
+		}
+		if err := r.outboxRepo.Create(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/auth"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+type AnalyticsService struct {
+	catRepo  storage.CategoryRepo
+	opRepo   storage.OperationRepo
+	authz    *auth.Authorizer
+	observer CallObserver
+}
+
+func NewAnalyticsService(catRepo storage.CategoryRepo, opRepo storage.OperationRepo, authz *auth.Authorizer, observer CallObserver) *AnalyticsService {
+	return &AnalyticsService{
+		catRepo:  catRepo,
+		opRepo:   opRepo,
+		authz:    authz,
+		observer: observerOrNop(observer),
+	}
+}
+
+// TotalsRequest narrows operations counted in a report.
+type TotalsRequest struct {
+	// AccountID is optional, all accounts visible to the user are counted when it's nil.
+	AccountID *uuid.UUID
+	// From is inclusive, To is exclusive. Both are optional.
+	From *time.Time
+	To   *time.Time
+}
+
+// CategoryTotals sums operations per category, rolling subcategory totals up into their parents.
+// A split operation is counted by its split amounts instead of its own category.
+func (s *AnalyticsService) CategoryTotals(ctx context.Context, req TotalsRequest) (_ []dto.CategoryTotalDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "AnalyticsService.CategoryTotals")
+	defer call.end(&err)
+
+	cats, err := s.catRepo.List(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	ops, err := findVisible(ctx, s.authz, s.opRepo, storage.OperationFilter{
+		AccountID: req.AccountID,
+		From:      req.From,
+		To:        req.To,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find operations: %w", err)
+	}
+
+	own := make(map[uuid.UUID]int64, len(cats))
+	total := make(map[uuid.UUID]int64, len(cats))
+	add := func(categoryID *uuid.UUID, amount int64) {
+		if categoryID == nil {
+			return
+		}
+		own[*categoryID] += amount
+		for _, id := range domain.CategoryPath(cats, *categoryID) {
+			total[id] += amount
+		}
+	}
+	for _, op := range ops {
+		if len(op.Splits) == 0 {
+			add(op.CategoryID, op.Amount)
+			continue
+		}
+		for _, split := range op.Splits {
+			add(split.CategoryID, split.Amount)
+		}
+	}
+
+	resp := make([]dto.CategoryTotalDTO, 0, len(cats))
+	for _, c := range cats {
+		resp = append(resp, dto.CategoryTotalDTO{
+			CategoryID: c.ID,
+			Name:       c.Name,
+			Type:       string(c.Type),
+			ParentID:   c.ParentID,
+			Own:        own[c.ID],
+			Total:      total[c.ID],
+		})
+	}
+	sort.Slice(resp, func(i, j int) bool { return resp[i].Total > resp[j].Total })
+	return resp, nil
+}
+
+// TagTotals sums incomes and outcomes per tag. An operation with several tags is counted in each of them.
+func (s *AnalyticsService) TagTotals(ctx context.Context, req TotalsRequest) (_ []dto.TagTotalDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "AnalyticsService.TagTotals")
+	defer call.end(&err)
+
+	ops, err := findVisible(ctx, s.authz, s.opRepo, storage.OperationFilter{
+		AccountID: req.AccountID,
+		From:      req.From,
+		To:        req.To,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find operations: %w", err)
+	}
+
+	totals := make(map[string]*dto.TagTotalDTO)
+	for _, op := range ops {
+		for _, tag := range op.Tags {
+			t, ok := totals[tag]
+			if !ok {
+				t = &dto.TagTotalDTO{Tag: tag}
+				totals[tag] = t
+			}
+			t.Operations++
+			switch op.Type {
+			case domain.OperationTypeIncome:
+				t.Income += op.Amount
+			case domain.OperationTypeOutcome:
+				t.Outcome += op.Amount
+			}
+		}
+	}
+
+	resp := make([]dto.TagTotalDTO, 0, len(totals))
+	for _, t := range totals {
+		resp = append(resp, *t)
+	}
+	sort.Slice(resp, func(i, j int) bool { return resp[i].Tag < resp[j].Tag })
+	return resp, nil
+}
+
+// anomalyLookback is the history operations since AnomaliesRequest.Since are compared with.
+const anomalyLookback = 90 * 24 * time.Hour
+
+type AnomaliesRequest struct {
+	// AccountID is optional, all accounts visible to the user are checked when it's nil.
+	AccountID *uuid.UUID
+	Since     time.Time
+}
+
+// Anomalies flags operations made since req.Since with unusual amounts, hours or repeated
+// right after the same operation, see domain.DetectAnomalies.
+func (s *AnalyticsService) Anomalies(ctx context.Context, req AnomaliesRequest) (_ []dto.AnomalyDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "AnalyticsService.Anomalies")
+	defer call.end(&err)
+
+	from := req.Since.Add(-anomalyLookback)
+	ops, err := findVisible(ctx, s.authz, s.opRepo, storage.OperationFilter{
+		AccountID: req.AccountID,
+		From:      &from,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find operations: %w", err)
+	}
+
+	anomalies := domain.DetectAnomalies(ops, req.Since, domain.DefaultAnomalyRules)
+	resp := make([]dto.AnomalyDTO, 0, len(anomalies))
+	for _, a := range anomalies {
+		resp = append(resp, *dto.NewAnomalyDTO(&a))
+	}
+	return resp, nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/auth"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+type AuditService struct {
+	auditRepo storage.AuditRepo
+	authz     *auth.Authorizer
+	observer  CallObserver
+}
+
+func NewAuditService(auditRepo storage.AuditRepo, authz *auth.Authorizer, observer CallObserver) *AuditService {
+	return &AuditService{
+		auditRepo: auditRepo,
+		authz:     authz,
+		observer:  observerOrNop(observer),
+	}
+}
+
+type ListAuditRequest struct {
+	// Entity is either an entity ID or an entity type, e.g. "account". Empty matches everything.
+	Entity string
+	Since  *time.Time
+}
+
+// List returns entries of accounts visible to the user, their operations and schedules,
+// and of categories and budgets, which are shared by the tenant.
+func (s *AuditService) List(ctx context.Context, req ListAuditRequest) (_ []dto.AuditEntryDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "AuditService.List")
+	defer call.end(&err)
+
+	filter := storage.AuditFilter{Since: req.Since}
+	if id, err := uuid.Parse(req.Entity); err == nil {
+		filter.EntityID = &id
+	} else if req.Entity != "" {
+		typ := domain.EntityType(req.Entity)
+		if !slices.Contains(domain.EntityTypes, typ) {
+			return nil, fmt.Errorf("%w %q, expected an ID or one of %v", domain.ErrUnknownEntityType, req.Entity, domain.EntityTypes)
+		}
+		filter.EntityType = typ
+	}
+
+	roles, err := s.authz.Roles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.auditRepo.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]dto.AuditEntryDTO, 0, len(entries))
+	for _, e := range entries {
+		if accountID, ok := auditAccountID(&e); ok {
+			if _, visible := roles[accountID]; !visible {
+				continue
+			}
+		}
+		resp = append(resp, *dto.NewAuditEntryDTO(&e))
+	}
+	return resp, nil
+}
@@ -2,24 +2,53 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/auth"
 	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
 	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
 	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
 )
 
 type BankAccountService struct {
-	accRepo storage.BankAccountRepo
+	tx         storage.Transactor
+	accRepo    storage.BankAccountRepo
+	eventRepo  storage.AccountEventRepo
+	userRepo   storage.UserRepo
+	memberRepo storage.MembershipRepo
+	authz      *auth.Authorizer
+	observer   CallObserver
 }
 
-func NewBankAccountService(repo storage.BankAccountRepo) *BankAccountService {
+func NewBankAccountService(
+	tx storage.Transactor,
+	repo storage.BankAccountRepo,
+	eventRepo storage.AccountEventRepo,
+	userRepo storage.UserRepo,
+	memberRepo storage.MembershipRepo,
+	authz *auth.Authorizer,
+	observer CallObserver,
+) *BankAccountService {
 	return &BankAccountService{
-		accRepo: repo,
+		tx:         tx,
+		accRepo:    repo,
+		eventRepo:  eventRepo,
+		userRepo:   userRepo,
+		memberRepo: memberRepo,
+		authz:      authz,
+		observer:   observerOrNop(observer),
 	}
 }
 
-func (s *BankAccountService) Get(ctx context.Context, id uuid.UUID) (*dto.BankAccountDTO, error) {
+func (s *BankAccountService) Get(ctx context.Context, id uuid.UUID) (_ *dto.BankAccountDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "BankAccountService.Get")
+	defer call.end(&err)
+
+	if err := s.authz.Require(ctx, id, domain.RoleViewer); err != nil {
+		return nil, err
+	}
 	acc, err := s.accRepo.Get(ctx, id)
 	if err != nil {
 		return nil, err
@@ -28,74 +57,276 @@ func (s *BankAccountService) Get(ctx context.Context, id uuid.UUID) (*dto.BankAc
 	return dto.NewBankAccountDTO(acc), nil
 }
 
-func (s *BankAccountService) List(ctx context.Context) ([]dto.BankAccountDTO, error) {
-	cats, err := s.accRepo.List(ctx)
+// List returns accounts the current user is a member of.
+func (s *BankAccountService) List(ctx context.Context, includeClosed bool) (_ []dto.BankAccountDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "BankAccountService.List")
+	defer call.end(&err)
+
+	roles, err := s.authz.Roles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cats, err := s.accRepo.List(ctx, includeClosed)
 	if err != nil {
 		return nil, err
 	}
 
 	resp := make([]dto.BankAccountDTO, 0, len(cats))
 	for _, c := range cats {
-		resp = append(resp, *dto.NewBankAccountDTO(&c))
+		if _, ok := roles[c.ID]; ok {
+			resp = append(resp, *dto.NewBankAccountDTO(&c))
+		}
 	}
 	return resp, nil
 }
 
-func (s *BankAccountService) CreateAccount(ctx context.Context, name string) (*dto.BankAccountDTO, error) {
-	acc, err := domain.NewBankAccount(name)
+type CreateAccountRequest struct {
+	Name           string
+	Currency       string
+	Type           string
+	OverdraftLimit int64
+}
+
+// CreateAccount creates an account owned by the current user.
+func (s *BankAccountService) CreateAccount(ctx context.Context, req CreateAccountRequest) (_ *dto.BankAccountDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "BankAccountService.CreateAccount")
+	defer call.end(&err)
+
+	user, err := auth.CurrentUser(ctx)
 	if err != nil {
 		return nil, err
 	}
+	acc, err := domain.NewBankAccount(req.Name, req.Currency, domain.AccountType(req.Type), req.OverdraftLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		events := acc.PullEvents()
+		var err error
+		if acc, err = s.accRepo.Create(ctx, acc); err != nil {
+			return err
+		}
+		if err := s.eventRepo.Append(ctx, events...); err != nil {
+			return err
+		}
+		return s.memberRepo.Save(ctx, &domain.Membership{AccountID: acc.ID, UserID: user.ID, Role: domain.RoleOwner})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dto.NewBankAccountDTO(acc), nil
+}
 
-	acc, err = s.accRepo.Create(ctx, acc)
+func (s *BankAccountService) Block(ctx context.Context, id uuid.UUID) (_ *dto.BankAccountDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "BankAccountService.Block")
+	defer call.end(&err)
 
-	return dto.NewBankAccountDTO(acc), err
+	return s.update(ctx, id, (*domain.BankAccount).Block)
 }
 
-func (s *BankAccountService) Block(ctx context.Context, id uuid.UUID) (*dto.BankAccountDTO, error) {
-	acc, err := s.accRepo.Get(ctx, id)
-	if err != nil {
+func (s *BankAccountService) Unblock(ctx context.Context, id uuid.UUID) (_ *dto.BankAccountDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "BankAccountService.Unblock")
+	defer call.end(&err)
+
+	return s.update(ctx, id, (*domain.BankAccount).Unblock)
+}
+
+func (s *BankAccountService) Restore(ctx context.Context, id uuid.UUID) (_ *dto.BankAccountDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "BankAccountService.Restore")
+	defer call.end(&err)
+
+	return s.update(ctx, id, (*domain.BankAccount).Restore)
+}
+
+func (s *BankAccountService) SetOverdraftLimit(ctx context.Context, id uuid.UUID, limit int64) (_ *dto.BankAccountDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "BankAccountService.SetOverdraftLimit")
+	defer call.end(&err)
+
+	return s.update(ctx, id, func(acc *domain.BankAccount) error {
+		return acc.SetOverdraftLimit(limit)
+	})
+}
+
+// update applies fn to the locked account, so it can't race with operations changing the balance.
+// Only owners may change the account.
+func (s *BankAccountService) update(ctx context.Context, id uuid.UUID, fn func(*domain.BankAccount) error) (*dto.BankAccountDTO, error) {
+	if err := s.authz.Require(ctx, id, domain.RoleOwner); err != nil {
 		return nil, err
 	}
+	var acc *domain.BankAccount
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if acc, err = s.accRepo.GetForUpdate(ctx, id); err != nil {
+			return err
+		}
+
+		if err := fn(acc); err != nil {
+			return err
+		}
 
-	if err := acc.Block(); err != nil {
+		events := acc.PullEvents()
+		if acc, err = s.accRepo.Update(ctx, acc); err != nil {
+			return err
+		}
+		return s.eventRepo.Append(ctx, events...)
+	})
+	if err != nil {
 		return nil, err
 	}
+	return dto.NewBankAccountDTO(acc), nil
+}
+
+// Delete closes an empty account. It is hidden from List but kept for its operations and history.
+func (s *BankAccountService) Delete(ctx context.Context, id uuid.UUID) (_ *dto.BankAccountDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "BankAccountService.Delete")
+	defer call.end(&err)
 
-	if acc, err = s.accRepo.Update(ctx, acc); err != nil {
+	if err := s.authz.Require(ctx, id, domain.RoleOwner); err != nil {
 		return nil, err
 	}
-	return dto.NewBankAccountDTO(acc), err
-}
+	var acc *domain.BankAccount
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if acc, err = s.accRepo.GetForUpdate(ctx, id); err != nil {
+			return err
+		}
 
-func (s *BankAccountService) Unblock(ctx context.Context, id uuid.UUID) (*dto.BankAccountDTO, error) {
-	acc, err := s.accRepo.Get(ctx, id)
+		if err := acc.Delete(); err != nil {
+			return err
+		}
+
+		events := acc.PullEvents()
+		if acc, err = s.accRepo.Delete(ctx, acc.ID); err != nil {
+			return err
+		}
+		return s.eventRepo.Append(ctx, events...)
+	})
 	if err != nil {
 		return nil, err
 	}
+	return dto.NewBankAccountDTO(acc), nil
+}
 
-	if err := acc.Unblock(); err != nil {
+// History rebuilds the account as it was at the given time by replaying its events.
+// Operations count by their own time, so a backdated one changes the past balance.
+func (s *BankAccountService) History(ctx context.Context, id uuid.UUID, at time.Time) (_ *dto.AccountHistoryDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "BankAccountService.History")
+	defer call.end(&err)
+
+	if err := s.authz.Require(ctx, id, domain.RoleViewer); err != nil {
+		return nil, err
+	}
+	events, err := s.eventRepo.ListByAccount(ctx, id, nil)
+	if err != nil {
 		return nil, err
 	}
+	events = domain.EventsAsOf(events, at)
 
-	if acc, err = s.accRepo.Update(ctx, acc); err != nil {
+	acc, deleted, err := domain.ReplayBankAccount(events)
+	if err != nil {
 		return nil, err
 	}
-	return dto.NewBankAccountDTO(acc), err
+
+	return &dto.AccountHistoryDTO{
+		At:      at,
+		Account: *dto.NewBankAccountDTO(acc),
+		Deleted: deleted,
+		Events:  len(events),
+	}, nil
 }
 
-func (s *BankAccountService) Delete(ctx context.Context, id uuid.UUID) (*dto.BankAccountDTO, error) {
-	acc, err := s.accRepo.Get(ctx, id)
+func (s *BankAccountService) Members(ctx context.Context, id uuid.UUID) (_ []dto.MemberDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "BankAccountService.Members")
+	defer call.end(&err)
+
+	if err := s.authz.Require(ctx, id, domain.RoleViewer); err != nil {
+		return nil, err
+	}
+	members, err := s.memberRepo.ListByAccount(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-
-	if err := acc.Delete(); err != nil {
+	users, err := s.userRepo.List(ctx)
+	if err != nil {
 		return nil, err
 	}
+	names := make(map[uuid.UUID]string, len(users))
+	for _, u := range users {
+		names[u.ID] = u.Name
+	}
 
-	if acc, err = s.accRepo.Delete(ctx, acc.ID); err != nil {
-		return nil, err
+	resp := make([]dto.MemberDTO, 0, len(members))
+	for _, m := range members {
+		resp = append(resp, dto.MemberDTO{
+			AccountID: m.AccountID,
+			UserID:    m.UserID,
+			UserName:  names[m.UserID],
+			Role:      string(m.Role),
+		})
 	}
-	return dto.NewBankAccountDTO(acc), err
+	return resp, nil
+}
+
+type SetMemberRequest struct {
+	AccountID uuid.UUID
+	UserName  string
+	Role      string
+}
+
+// SetMember shares the account with a user or changes their role, only owners may do it.
+func (s *BankAccountService) SetMember(ctx context.Context, req SetMemberRequest) (err error) {
+	ctx, call := startCall(ctx, s.observer, "BankAccountService.SetMember")
+	defer call.end(&err)
+
+	role, err := domain.ParseRole(req.Role)
+	if err != nil {
+		return err
+	}
+	return s.changeMember(ctx, req.AccountID, req.UserName, role)
+}
+
+// RemoveMember revokes access of a user. Owners may remove anyone, others only themselves.
+func (s *BankAccountService) RemoveMember(ctx context.Context, accountID uuid.UUID, userName string) (err error) {
+	ctx, call := startCall(ctx, s.observer, "BankAccountService.RemoveMember")
+	defer call.end(&err)
+
+	return s.changeMember(ctx, accountID, userName, "")
+}
+
+// changeMember sets the role of the user on the account, an empty role removes the user.
+func (s *BankAccountService) changeMember(ctx context.Context, accountID uuid.UUID, userName string, role domain.Role) error {
+	current, err := auth.CurrentUser(ctx)
+	if err != nil {
+		return err
+	}
+	user, err := s.userRepo.GetByName(ctx, userName)
+	if err != nil {
+		return fmt.Errorf("failed to get user %q: %w", userName, err)
+	}
+	leaving := role == "" && user.ID == current.ID
+	if !leaving {
+		if err := s.authz.Require(ctx, accountID, domain.RoleOwner); err != nil {
+			return err
+		}
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// The account lock serializes member changes, so that two owners can't demote each other at once.
+		if _, err := s.accRepo.GetForUpdate(ctx, accountID); err != nil {
+			return err
+		}
+		members, err := s.memberRepo.ListByAccount(ctx, accountID)
+		if err != nil {
+			return err
+		}
+		if err := domain.ChangeMember(members, user.ID, role); err != nil {
+			return err
+		}
+
+		if role == "" {
+			return s.memberRepo.Delete(ctx, accountID, user.ID)
+		}
+		return s.memberRepo.Save(ctx, &domain.Membership{AccountID: accountID, UserID: user.ID, Role: role})
+	})
 }
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/auth"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+type BudgetService struct {
+	budgetRepo storage.BudgetRepo
+	catRepo    storage.CategoryRepo
+	opRepo     storage.OperationRepo
+	authz      *auth.Authorizer
+	observer   CallObserver
+}
+
+func NewBudgetService(
+	budgetRepo storage.BudgetRepo,
+	catRepo storage.CategoryRepo,
+	opRepo storage.OperationRepo,
+	authz *auth.Authorizer,
+	observer CallObserver,
+) *BudgetService {
+	return &BudgetService{
+		budgetRepo: budgetRepo,
+		catRepo:    catRepo,
+		opRepo:     opRepo,
+		authz:      authz,
+		observer:   observerOrNop(observer),
+	}
+}
+
+// Set creates a budget for the category and period or changes the limit of the existing one.
+// Budgets are shared by the tenant, any authenticated user may set them.
+func (s *BudgetService) Set(ctx context.Context, categoryID uuid.UUID, period string, limit int64) (_ *dto.BudgetDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "BudgetService.Set")
+	defer call.end(&err)
+
+	if _, err := auth.CurrentUser(ctx); err != nil {
+		return nil, err
+	}
+
+	budgets, err := s.budgetRepo.ListByCategory(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range budgets {
+		if b.Period != domain.BudgetPeriod(period) {
+			continue
+		}
+		if err := b.SetLimit(limit); err != nil {
+			return nil, err
+		}
+		updated, err := s.budgetRepo.Update(ctx, &b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save budget: %w", err)
+		}
+		return dto.NewBudgetDTO(updated), nil
+	}
+
+	cat, err := s.catRepo.Get(ctx, categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	budget, err := domain.NewBudget(cat, domain.BudgetPeriod(period), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	budget, err = s.budgetRepo.Create(ctx, budget)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save budget: %w", err)
+	}
+	return dto.NewBudgetDTO(budget), nil
+}
+
+func (s *BudgetService) List(ctx context.Context) (_ []dto.BudgetDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "BudgetService.List")
+	defer call.end(&err)
+
+	budgets, err := s.budgetRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]dto.BudgetDTO, 0, len(budgets))
+	for _, b := range budgets {
+		resp = append(resp, *dto.NewBudgetDTO(&b))
+	}
+	return resp, nil
+}
+
+// Status reports spending against every budget for the current period.
+func (s *BudgetService) Status(ctx context.Context) (_ []dto.BudgetStatusDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "BudgetService.Status")
+	defer call.end(&err)
+
+	budgets, err := s.budgetRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cats, err := s.catRepo.List(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]dto.BudgetStatusDTO, 0, len(budgets))
+	for _, b := range budgets {
+		status, err := s.status(ctx, &b, cats, 0, domain.TimeFunc())
+		if err != nil {
+			return nil, err
+		}
+		resp = append(resp, *status)
+	}
+	return resp, nil
+}
+
+// Check returns budgets that an outcome of amount in the category made at the given time would push
+// over the limit in the period containing that time, e.g. a past month for a backdated outcome.
+// Budgets of parent categories are checked too, since child spending rolls up into them.
+func (s *BudgetService) Check(ctx context.Context, categoryID uuid.UUID, amount int64, at time.Time) (_ []dto.BudgetStatusDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "BudgetService.Check")
+	defer call.end(&err)
+
+	cats, err := s.catRepo.List(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var budgets []domain.Budget
+	for _, id := range domain.CategoryPath(cats, categoryID) {
+		bs, err := s.budgetRepo.ListByCategory(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, bs...)
+	}
+
+	var exceeded []dto.BudgetStatusDTO
+	for _, b := range budgets {
+		status, err := s.status(ctx, &b, cats, amount, at)
+		if err != nil {
+			return nil, err
+		}
+		if status.Overspent {
+			exceeded = append(exceeded, *status)
+		}
+	}
+	return exceeded, nil
+}
+
+// status sums outcomes of the budget category and all its subcategories made from accounts
+// visible to the user in the budget period containing at. A split operation counts
+// by its parts in those categories, like in analytics.
+func (s *BudgetService) status(ctx context.Context, budget *domain.Budget, cats []domain.Category, extra int64, at time.Time) (*dto.BudgetStatusDTO, error) {
+	from, to := budget.PeriodBounds(at)
+	subtree := domain.CategorySubtree(cats, budget.CategoryID)
+	ops, err := findVisible(ctx, s.authz, s.opRepo, storage.OperationFilter{
+		Type:        domain.OperationTypeOutcome,
+		CategoryIDs: subtree,
+		From:        &from,
+		To:          &to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	inBudget := make(map[uuid.UUID]bool, len(subtree))
+	for _, id := range subtree {
+		inBudget[id] = true
+	}
+	spent := extra
+	for _, op := range ops {
+		if len(op.Splits) == 0 {
+			spent += op.Amount
+			continue
+		}
+		for _, split := range op.Splits {
+			if split.CategoryID != nil && inBudget[*split.CategoryID] {
+				spent += split.Amount
+			}
+		}
+	}
+
+	return &dto.BudgetStatusDTO{
+		BudgetDTO: *dto.NewBudgetDTO(budget),
+		From:      from,
+		To:        to,
+		Spent:     spent,
+		Remaining: budget.Limit - spent,
+		Overspent: spent > budget.Limit,
+	}, nil
+}
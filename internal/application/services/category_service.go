@@ -1,27 +1,63 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/auth"
 	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
 	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
 	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
 )
 
+// CategoryService manages categories, which are shared by all users of the tenant,
+// so any authenticated user may change them.
 type CategoryService struct {
-	catRepo storage.CategoryRepo
+	tx           storage.Transactor
+	catRepo      storage.CategoryRepo
+	opRepo       storage.OperationRepo
+	scheduleRepo storage.ScheduleRepo
+	observer     CallObserver
 }
 
-func NewCategoryService(catRepo storage.CategoryRepo) *CategoryService {
+func NewCategoryService(
+	tx storage.Transactor,
+	catRepo storage.CategoryRepo,
+	opRepo storage.OperationRepo,
+	scheduleRepo storage.ScheduleRepo,
+	observer CallObserver,
+) *CategoryService {
 	return &CategoryService{
-		catRepo: catRepo,
+		tx:           tx,
+		catRepo:      catRepo,
+		opRepo:       opRepo,
+		scheduleRepo: scheduleRepo,
+		observer:     observerOrNop(observer),
 	}
 }
 
-func (s *CategoryService) Create(ctx context.Context, typ string, name string) (*dto.CategoryDTO, error) {
-	category, err := domain.NewCategory(domain.CategoryType(typ), name)
+// Create creates a root category, or a child of parentID when it's set.
+func (s *CategoryService) Create(ctx context.Context, typ string, name string, parentID *uuid.UUID) (_ *dto.CategoryDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "CategoryService.Create")
+	defer call.end(&err)
+
+	if _, err := auth.CurrentUser(ctx); err != nil {
+		return nil, err
+	}
+
+	var category *domain.Category
+	if parentID == nil {
+		category, err = domain.NewCategory(domain.CategoryType(typ), name)
+	} else {
+		parent, getErr := s.catRepo.Get(ctx, *parentID)
+		if getErr != nil {
+			return nil, fmt.Errorf("failed to get parent category: %w", getErr)
+		}
+		category, err = domain.NewSubcategory(parent, domain.CategoryType(typ), name)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create category: %w", err)
 	}
@@ -34,7 +70,10 @@ func (s *CategoryService) Create(ctx context.Context, typ string, name string) (
 	return dto.NewCategoryDTO(category), nil
 }
 
-func (s *CategoryService) Get(ctx context.Context, id uuid.UUID) (*dto.CategoryDTO, error) {
+func (s *CategoryService) Get(ctx context.Context, id uuid.UUID) (_ *dto.CategoryDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "CategoryService.Get")
+	defer call.end(&err)
+
 	category, err := s.catRepo.Get(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get category: %w", err)
@@ -43,8 +82,11 @@ func (s *CategoryService) Get(ctx context.Context, id uuid.UUID) (*dto.CategoryD
 	return dto.NewCategoryDTO(category), nil
 }
 
-func (s *CategoryService) List(ctx context.Context) ([]dto.CategoryDTO, error) {
-	cats, err := s.catRepo.List(ctx)
+func (s *CategoryService) List(ctx context.Context, includeDeleted bool) (_ []dto.CategoryDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "CategoryService.List")
+	defer call.end(&err)
+
+	cats, err := s.catRepo.List(ctx, includeDeleted)
 	if err != nil {
 		return nil, err
 	}
@@ -56,11 +98,334 @@ func (s *CategoryService) List(ctx context.Context) ([]dto.CategoryDTO, error) {
 	return resp, nil
 }
 
-func (s *CategoryService) Delete(ctx context.Context, id uuid.UUID) (*dto.CategoryDTO, error) {
-	category, err := s.catRepo.Delete(ctx, id)
+func (s *CategoryService) Tree(ctx context.Context) (_ []dto.CategoryTreeDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "CategoryService.Tree")
+	defer call.end(&err)
+
+	cats, err := s.catRepo.List(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewCategoryTreeDTO(cats), nil
+}
+
+// Move puts the category under parentID, or makes it a root when parentID is nil.
+func (s *CategoryService) Move(ctx context.Context, id uuid.UUID, parentID *uuid.UUID) (_ *dto.CategoryDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "CategoryService.Move")
+	defer call.end(&err)
+
+	if _, err := auth.CurrentUser(ctx); err != nil {
+		return nil, err
+	}
+
+	var category *domain.Category
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		locked, cats, err := s.lockMove(ctx, id, parentID)
+		if err != nil {
+			return err
+		}
+		category = locked[id]
+
+		var parent *domain.Category
+		if parentID != nil {
+			parent = locked[*parentID]
+		}
+		if err := category.SetParent(parent, cats); err != nil {
+			return err
+		}
+
+		category, err = s.catRepo.Update(ctx, category)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to move category: %w", err)
+	}
+	return dto.NewCategoryDTO(category), nil
+}
+
+// lockMove locks the category and the new parent with all its ancestors, and returns them with
+// all categories read after locking. A concurrent move making the category an ancestor of the parent
+// has to lock one of the ancestors, so two moves can't create a cycle together. Categories are
+// locked in ID order, the ancestors are read again until every one of them is locked.
+func (s *CategoryService) lockMove(ctx context.Context, id uuid.UUID, parentID *uuid.UUID) (map[uuid.UUID]*domain.Category, []domain.Category, error) {
+	locked := make(map[uuid.UUID]*domain.Category)
+	for {
+		cats, err := s.catRepo.List(ctx, true)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var missing []uuid.UUID
+		need := []uuid.UUID{id}
+		if parentID != nil {
+			need = append(need, domain.CategoryPath(cats, *parentID)...)
+		}
+		for _, n := range need {
+			if _, ok := locked[n]; !ok && !slices.Contains(missing, n) {
+				missing = append(missing, n)
+			}
+		}
+		if len(missing) == 0 {
+			return locked, cats, nil
+		}
+
+		slices.SortFunc(missing, func(a, b uuid.UUID) int { return bytes.Compare(a[:], b[:]) })
+		for _, n := range missing {
+			cat, err := s.catRepo.GetForUpdate(ctx, n)
+			if err != nil {
+				if n == id {
+					return nil, nil, fmt.Errorf("failed to get category: %w", err)
+				}
+				return nil, nil, fmt.Errorf("failed to get parent category: %w", err)
+			}
+			locked[n] = cat
+		}
+	}
+}
+
+func (s *CategoryService) Rename(ctx context.Context, id uuid.UUID, name string) (_ *dto.CategoryDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "CategoryService.Rename")
+	defer call.end(&err)
+
+	if _, err := auth.CurrentUser(ctx); err != nil {
+		return nil, err
+	}
+
+	var category *domain.Category
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if category, err = s.catRepo.GetForUpdate(ctx, id); err != nil {
+			return err
+		}
+
+		if err := category.Rename(name); err != nil {
+			return err
+		}
+
+		category, err = s.catRepo.Update(ctx, category)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename category: %w", err)
+	}
+
+	return dto.NewCategoryDTO(category), nil
+}
+
+type RemoveCategoryResponse struct {
+	Deleted      *dto.CategoryDTO `json:"deleted"`
+	ReassignedTo *dto.CategoryDTO `json:"reassigned_to,omitempty"`
+	// AffectedOperations are moved to ReassignedTo, or keep the deleted category if it's nil.
+	AffectedOperations int64 `json:"affected_operations"`
+	// AffectedSchedules are moved to ReassignedTo, or left without a category if it's nil.
+	AffectedSchedules int64 `json:"affected_schedules"`
+}
+
+// Merge moves operations, scheduled operations and subcategories of from into the into category
+// and deletes from, all in one transaction. Budgets of from are kept and come back on restore.
+func (s *CategoryService) Merge(ctx context.Context, from, into uuid.UUID) (_ *RemoveCategoryResponse, err error) {
+	ctx, call := startCall(ctx, s.observer, "CategoryService.Merge")
+	defer call.end(&err)
+
+	if _, err := auth.CurrentUser(ctx); err != nil {
+		return nil, err
+	}
+
+	var resp *RemoveCategoryResponse
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = s.merge(ctx, from, into)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge categories: %w", err)
+	}
+
+	return resp, nil
+}
+
+func (s *CategoryService) merge(ctx context.Context, fromID, intoID uuid.UUID) (*RemoveCategoryResponse, error) {
+	from, into, err := s.lockPair(ctx, fromID, intoID)
+	if err != nil {
+		return nil, err
+	}
+	cats, err := s.catRepo.List(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := from.CanMergeInto(into, cats); err != nil {
+		return nil, err
+	}
+	if err := from.Delete(); err != nil {
+		return nil, err
+	}
+
+	ops, err := s.opRepo.ReassignCategory(ctx, from.ID, into.ID)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := s.scheduleRepo.ReassignCategory(ctx, from.ID, &into.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reparentChildren(ctx, cats, from.ID, &into.ID); err != nil {
+		return nil, err
+	}
+
+	deleted, err := s.catRepo.Delete(ctx, from.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RemoveCategoryResponse{
+		Deleted:            dto.NewCategoryDTO(deleted),
+		ReassignedTo:       dto.NewCategoryDTO(into),
+		AffectedOperations: ops,
+		AffectedSchedules:  schedules,
+	}, nil
+}
+
+// lockPair locks both categories in a stable order so that concurrent merges can't deadlock.
+func (s *CategoryService) lockPair(ctx context.Context, fromID, intoID uuid.UUID) (from, into *domain.Category, err error) {
+	if fromID == intoID {
+		return nil, nil, domain.ErrMergeIntoItself
+	}
+
+	first, second := fromID, intoID
+	if bytes.Compare(first[:], second[:]) > 0 {
+		first, second = second, first
+	}
+
+	locked := make(map[uuid.UUID]*domain.Category, 2)
+	for _, id := range []uuid.UUID{first, second} {
+		cat, err := s.catRepo.GetForUpdate(ctx, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		locked[id] = cat
+	}
+	return locked[fromID], locked[intoID], nil
+}
+
+// reparentChildren moves direct children of the category under parentID.
+func (s *CategoryService) reparentChildren(ctx context.Context, cats []domain.Category, id uuid.UUID, parentID *uuid.UUID) error {
+	for _, c := range cats {
+		if c.ParentID == nil || *c.ParentID != id {
+			continue
+		}
+		c.ParentID = parentID
+		if _, err := s.catRepo.Update(ctx, &c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type DeleteCategoryRequest struct {
+	ID uuid.UUID
+	// ReassignTo makes Delete work as Merge into that category.
+	ReassignTo *uuid.UUID
+	// Force deletes the category even if operations reference it, they keep the deleted category.
+	Force bool
+}
+
+// Delete refuses to delete a category used by operations unless ReassignTo or Force is set.
+// Then it marks the category deleted. Operations keep referencing it, so past reports don't change,
+// scheduled operations are left without a category and subcategories are moved to its parent.
+func (s *CategoryService) Delete(ctx context.Context, req DeleteCategoryRequest) (_ *RemoveCategoryResponse, err error) {
+	ctx, call := startCall(ctx, s.observer, "CategoryService.Delete")
+	defer call.end(&err)
+
+	if _, err := auth.CurrentUser(ctx); err != nil {
+		return nil, err
+	}
+
+	if req.ReassignTo != nil {
+		return s.Merge(ctx, req.ID, *req.ReassignTo)
+	}
+
+	var resp *RemoveCategoryResponse
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		category, err := s.catRepo.GetForUpdate(ctx, req.ID)
+		if err != nil {
+			return err
+		}
+
+		ops, err := s.opRepo.CountByCategory(ctx, category.ID)
+		if err != nil {
+			return err
+		}
+		if ops > 0 && !req.Force {
+			return fmt.Errorf("%w: %d operation(s), use reassign or force", domain.ErrCategoryInUse, ops)
+		}
+
+		if err := category.Delete(); err != nil {
+			return err
+		}
+		schedules, err := s.scheduleRepo.ReassignCategory(ctx, category.ID, nil)
+		if err != nil {
+			return err
+		}
+
+		cats, err := s.catRepo.List(ctx, true)
+		if err != nil {
+			return err
+		}
+		if err := s.reparentChildren(ctx, cats, category.ID, category.ParentID); err != nil {
+			return err
+		}
+
+		deleted, err := s.catRepo.Delete(ctx, category.ID)
+		if err != nil {
+			return err
+		}
+		resp = &RemoveCategoryResponse{
+			Deleted:            dto.NewCategoryDTO(deleted),
+			AffectedOperations: ops,
+			AffectedSchedules:  schedules,
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to delete category: %w", err)
 	}
 
+	return resp, nil
+}
+
+// Restore brings back a deleted category under its former parent.
+func (s *CategoryService) Restore(ctx context.Context, id uuid.UUID) (_ *dto.CategoryDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "CategoryService.Restore")
+	defer call.end(&err)
+
+	if _, err := auth.CurrentUser(ctx); err != nil {
+		return nil, err
+	}
+
+	var category *domain.Category
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if category, err = s.catRepo.GetForUpdate(ctx, id); err != nil {
+			return err
+		}
+
+		var parent *domain.Category
+		if category.ParentID != nil {
+			if parent, err = s.catRepo.GetForUpdate(ctx, *category.ParentID); err != nil {
+				return fmt.Errorf("failed to get parent category: %w", err)
+			}
+		}
+		if err := category.Restore(parent); err != nil {
+			return err
+		}
+
+		category, err = s.catRepo.Update(ctx, category)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore category: %w", err)
+	}
+
 	return dto.NewCategoryDTO(category), nil
 }
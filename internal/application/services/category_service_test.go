@@ -0,0 +1,81 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/auth"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/services"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+// memCategories keeps categories in memory and remembers which ones were locked.
+type memCategories struct {
+	storage.CategoryRepo
+	cats   map[uuid.UUID]domain.Category
+	locked []uuid.UUID
+}
+
+func (r *memCategories) List(context.Context, bool) ([]domain.Category, error) {
+	cats := make([]domain.Category, 0, len(r.cats))
+	for _, c := range r.cats {
+		cats = append(cats, c)
+	}
+	return cats, nil
+}
+
+func (r *memCategories) GetForUpdate(_ context.Context, id uuid.UUID) (*domain.Category, error) {
+	c, ok := r.cats[id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	r.locked = append(r.locked, id)
+	return &c, nil
+}
+
+func (r *memCategories) Update(_ context.Context, cat *domain.Category) (*domain.Category, error) {
+	r.cats[cat.ID] = *cat
+	return cat, nil
+}
+
+func TestMoveLocksAncestorsOfParent(t *testing.T) {
+	repo := &memCategories{cats: make(map[uuid.UUID]domain.Category)}
+	add := func(parent *domain.Category) *domain.Category {
+		c, err := domain.NewCategory(domain.CategoryTypeOutcome, "c")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parent != nil {
+			c.ParentID = &parent.ID
+		}
+		repo.cats[c.ID] = *c
+		return c
+	}
+	root := add(nil)
+	child := add(root)
+	grandchild := add(child)
+	moved := add(nil)
+
+	svc := services.NewCategoryService(noTx{}, repo, nil, nil, nil)
+	ctx := auth.WithUser(context.Background(), &domain.User{ID: uuid.New(), Name: "alice"})
+
+	if _, err := svc.Move(ctx, moved.ID, &grandchild.ID); err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	for _, id := range []uuid.UUID{moved.ID, grandchild.ID, child.ID, root.ID} {
+		found := false
+		for _, l := range repo.locked {
+			found = found || l == id
+		}
+		if !found {
+			t.Errorf("category %s wasn't locked, locked %v", id, repo.locked)
+		}
+	}
+
+	if _, err := svc.Move(ctx, root.ID, &grandchild.ID); !errors.Is(err, domain.ErrCategoryCycle) {
+		t.Errorf("moving root under its grandchild: err = %v, want ErrCategoryCycle", err)
+	}
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/auth"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+type ForecastService struct {
+	accRepo      storage.BankAccountRepo
+	opRepo       storage.OperationRepo
+	catRepo      storage.CategoryRepo
+	scheduleRepo storage.ScheduleRepo
+	authz        *auth.Authorizer
+	observer     CallObserver
+}
+
+func NewForecastService(
+	accRepo storage.BankAccountRepo,
+	opRepo storage.OperationRepo,
+	catRepo storage.CategoryRepo,
+	scheduleRepo storage.ScheduleRepo,
+	authz *auth.Authorizer,
+	observer CallObserver,
+) *ForecastService {
+	return &ForecastService{
+		accRepo:      accRepo,
+		opRepo:       opRepo,
+		catRepo:      catRepo,
+		scheduleRepo: scheduleRepo,
+		authz:        authz,
+		observer:     observerOrNop(observer),
+	}
+}
+
+type ForecastRequest struct {
+	AccountID uuid.UUID
+	// Days to project, starting from today.
+	Days int
+	// LookbackDays of history are averaged per category.
+	LookbackDays int
+}
+
+// Forecast projects the daily balance of the account. Every day gets the occurrences of its
+// scheduled operations plus the daily average of each category over the lookback period.
+// Operations produced by schedules are left out of the averages, they are projected exactly,
+// and split operations count towards their split categories.
+// Occurrences due but not materialized yet are put on the first day.
+func (s *ForecastService) Forecast(ctx context.Context, req ForecastRequest) (_ *dto.ForecastDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "ForecastService.Forecast")
+	defer call.end(&err)
+
+	if req.Days <= 0 || req.LookbackDays <= 0 {
+		return nil, domain.ErrInvalidPeriod
+	}
+	if err := s.authz.Require(ctx, req.AccountID, domain.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	acc, err := s.accRepo.Get(ctx, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if acc.ClosedAt != nil {
+		return nil, domain.ErrAccountClosed
+	}
+
+	now := domain.TimeFunc()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	until := from.AddDate(0, 0, req.Days)
+
+	scheduled, scheduledOps, err := s.scheduledChanges(ctx, acc.ID, from, until)
+	if err != nil {
+		return nil, err
+	}
+	averages, err := s.categoryAverages(ctx, acc.ID, from, req.LookbackDays, scheduledOps)
+	if err != nil {
+		return nil, err
+	}
+	var daily float64
+	for _, a := range averages {
+		daily += a.Daily
+	}
+
+	forecast := &dto.ForecastDTO{
+		AccountID:        acc.ID,
+		From:             from,
+		Days:             req.Days,
+		StartBalance:     acc.Balance,
+		LookbackDays:     req.LookbackDays,
+		CategoryAverages: averages,
+		Points:           make([]dto.ForecastPointDTO, 0, req.Days),
+	}
+	balance := acc.Balance
+	for i := 0; i < req.Days; i++ {
+		day := from.AddDate(0, 0, i)
+		// Rounding the running sum instead of every day keeps small averages from vanishing.
+		estimated := int64(math.Round(daily*float64(i+1))) - int64(math.Round(daily*float64(i)))
+		balance += scheduled[i] + estimated
+		forecast.Points = append(forecast.Points, dto.ForecastPointDTO{
+			Date:      day,
+			Scheduled: scheduled[i],
+			Estimated: estimated,
+			Balance:   balance,
+		})
+		if balance < 0 && forecast.FirstNegative == nil {
+			forecast.FirstNegative = &day
+		}
+	}
+
+	return forecast, nil
+}
+
+// scheduledChanges sums occurrences of the account schedules per day before until, and returns
+// IDs of operations the schedules have already produced.
+func (s *ForecastService) scheduledChanges(ctx context.Context, accountID uuid.UUID, from, until time.Time) (map[int]int64, map[uuid.UUID]bool, error) {
+	schedules, err := s.scheduleRepo.List(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	changes := make(map[int]int64)
+	produced := make(map[uuid.UUID]bool)
+	for _, sch := range schedules {
+		if sch.AccountID != accountID {
+			continue
+		}
+
+		occurrences, err := s.scheduleRepo.ListOccurrences(ctx, sch.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		var last *time.Time
+		for _, o := range occurrences {
+			produced[o.OperationID] = true
+			last = &o.OccursAt
+		}
+
+		due, err := sch.Due(last, until)
+		if err != nil {
+			return nil, nil, err
+		}
+		change := (&domain.Operation{Type: sch.Type, Amount: sch.Amount}).BalanceChange()
+		for _, at := range due {
+			if !at.Before(until) {
+				break
+			}
+			changes[daysBetween(from, at)] += change
+		}
+	}
+	return changes, produced, nil
+}
+
+// categoryAverages returns the average daily balance change per category over the lookback
+// days before from, biggest spending first.
+func (s *ForecastService) categoryAverages(
+	ctx context.Context,
+	accountID uuid.UUID,
+	from time.Time,
+	lookbackDays int,
+	skip map[uuid.UUID]bool,
+) ([]dto.CategoryAverageDTO, error) {
+	since := from.AddDate(0, 0, -lookbackDays)
+	ops, err := s.opRepo.Find(ctx, storage.OperationFilter{AccountID: &accountID, From: &since, To: &from})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find operations: %w", err)
+	}
+	cats, err := s.catRepo.List(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uuid.UUID]string, len(cats))
+	for _, c := range cats {
+		names[c.ID] = c.Name
+	}
+
+	sums := make(map[uuid.UUID]int64)
+	var uncategorized int64
+	for _, op := range ops {
+		if skip[op.ID] {
+			continue
+		}
+		sign := op.BalanceChange() / op.Amount
+		parts := op.Splits
+		if len(parts) == 0 {
+			parts = []domain.Split{{CategoryID: op.CategoryID, Amount: op.Amount}}
+		}
+		for _, p := range parts {
+			if p.CategoryID == nil {
+				uncategorized += sign * p.Amount
+				continue
+			}
+			sums[*p.CategoryID] += sign * p.Amount
+		}
+	}
+
+	averages := make([]dto.CategoryAverageDTO, 0, len(sums)+1)
+	for id, sum := range sums {
+		averages = append(averages, dto.CategoryAverageDTO{
+			CategoryID: &id,
+			Name:       names[id],
+			Daily:      float64(sum) / float64(lookbackDays),
+		})
+	}
+	if uncategorized != 0 {
+		averages = append(averages, dto.CategoryAverageDTO{
+			Name:  "uncategorized",
+			Daily: float64(uncategorized) / float64(lookbackDays),
+		})
+	}
+	sort.Slice(averages, func(i, j int) bool { return averages[i].Daily < averages[j].Daily })
+	return averages, nil
+}
+
+// daysBetween counts calendar days from the day of from to the day of t, at least 0.
+// It doesn't divide durations, so that days shortened by DST still count as whole ones.
+func daysBetween(from, t time.Time) int {
+	t = t.In(from.Location())
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return max(0, int(b.Sub(a).Hours()/24))
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/auth"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+// idempotent runs fn once per key of the current user and returns the saved response for repeated requests.
+// It must be called within a transaction, so that the key is saved only if fn succeeds.
+// An empty key disables the check.
+func idempotent[T any](
+	ctx context.Context,
+	repo storage.IdempotencyRepo,
+	key, kind string,
+	req any,
+	fn func(context.Context) (*T, error),
+) (*T, error) {
+	if key == "" {
+		return fn(ctx)
+	}
+
+	var userID uuid.UUID
+	if user := auth.UserFrom(ctx); user != nil {
+		userID = user.ID
+	}
+	k, err := domain.NewIdempotencyKey(userID, key, kind, req)
+	if err != nil {
+		return nil, err
+	}
+	created, err := repo.Create(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		stored, err := repo.Get(ctx, userID, key)
+		if err != nil {
+			return nil, err
+		}
+		var resp T
+		if err := stored.Replay(k, &resp); err != nil {
+			return nil, err
+		}
+		return &resp, nil
+	}
+
+	resp, err := fn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := k.SetResponse(resp); err != nil {
+		return nil, err
+	}
+	if err := repo.Update(ctx, k); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
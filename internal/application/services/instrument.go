@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer makes spans of service calls with the global tracer provider, a no-op unless it's set up.
+var tracer = otel.Tracer("github.com/sunnyyssh/designing-software-cw1/internal/application/services")
+
+// CallObserver is told how long every service call took and how it ended, e.g. to export metrics.
+type CallObserver interface {
+	// ObserveCall gets the method name like "OperationService.Transfer".
+	ObserveCall(method string, elapsed time.Duration, err error)
+}
+
+type noCallObserver struct{}
+
+func (noCallObserver) ObserveCall(string, time.Duration, error) {}
+
+// observerOrNop lets services be built with a nil observer, calls are then not observed.
+func observerOrNop(o CallObserver) CallObserver {
+	if o == nil {
+		return noCallObserver{}
+	}
+	return o
+}
+
+// call is a service method call in progress, it is traced and observed.
+type call struct {
+	method   string
+	start    time.Time
+	span     trace.Span
+	observer CallObserver
+}
+
+// startCall starts a span of the method, end the call with defer and a pointer to the returned error.
+func startCall(ctx context.Context, observer CallObserver, method string) (context.Context, *call) {
+	ctx, span := tracer.Start(ctx, method)
+	return ctx, &call{method: method, start: time.Now(), span: span, observer: observer}
+}
+
+func (c *call) end(err *error) {
+	c.observer.ObserveCall(c.method, time.Since(c.start), *err)
+	c.span.End()
+}
@@ -0,0 +1,46 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/services"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+)
+
+type observedCall struct {
+	method string
+	err    error
+}
+
+type callRecorder struct {
+	calls []observedCall
+}
+
+func (r *callRecorder) ObserveCall(method string, _ time.Duration, err error) {
+	r.calls = append(r.calls, observedCall{method: method, err: err})
+}
+
+func TestCallsAreObservedByMethod(t *testing.T) {
+	rec := &callRecorder{}
+	svc := services.NewOutboxService(noTx{}, &memOutbox{}, nil, rec)
+	if _, err := svc.List(context.Background(), "dead"); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if _, err := svc.Requeue(context.Background(), uuid.New()); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("Requeue: err = %v, want ErrNotFound", err)
+	}
+
+	if len(rec.calls) != 2 {
+		t.Fatalf("observed %+v, want 2 calls", rec.calls)
+	}
+	if c := rec.calls[0]; c.method != "OutboxService.List" || c.err != nil {
+		t.Errorf("first call = %+v, want OutboxService.List without error", c)
+	}
+	if c := rec.calls[1]; c.method != "OutboxService.Requeue" || !errors.Is(c.err, storage.ErrNotFound) {
+		t.Errorf("second call = %+v, want OutboxService.Requeue with ErrNotFound", c)
+	}
+}
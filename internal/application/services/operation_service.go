@@ -1,39 +1,87 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/auth"
 	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
 	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
 	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
 )
 
 type OperationService struct {
-	accRepo storage.BankAccountRepo
-	opRepo  storage.OperationRepo
+	tx         storage.Transactor
+	accRepo    storage.BankAccountRepo
+	opRepo     storage.OperationRepo
+	catRepo    storage.CategoryRepo
+	eventRepo  storage.AccountEventRepo
+	outboxRepo storage.OutboxRepo
+	idemRepo   storage.IdempotencyRepo
+	budgets    *BudgetService
+	authz      *auth.Authorizer
+	// maxFuture is how far ahead of now an operation time may be set.
+	maxFuture time.Duration
+	observer  CallObserver
 }
 
 func NewOperationService(
+	tx storage.Transactor,
 	repo storage.BankAccountRepo,
 	opRepo storage.OperationRepo,
+	catRepo storage.CategoryRepo,
+	eventRepo storage.AccountEventRepo,
+	outboxRepo storage.OutboxRepo,
+	idemRepo storage.IdempotencyRepo,
+	budgets *BudgetService,
+	authz *auth.Authorizer,
+	maxFuture time.Duration,
+	observer CallObserver,
 ) *OperationService {
 	return &OperationService{
-		accRepo: repo,
-		opRepo:  opRepo,
+		tx:         tx,
+		accRepo:    repo,
+		opRepo:     opRepo,
+		catRepo:    catRepo,
+		eventRepo:  eventRepo,
+		outboxRepo: outboxRepo,
+		idemRepo:   idemRepo,
+		budgets:    budgets,
+		authz:      authz,
+		maxFuture:  maxFuture,
+		observer:   observerOrNop(observer),
 	}
 }
 
-func (s *OperationService) Get(ctx context.Context, id uuid.UUID) (*dto.OperationDTO, error) {
+func (s *OperationService) Get(ctx context.Context, id uuid.UUID) (_ *dto.OperationDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "OperationService.Get")
+	defer call.end(&err)
+
 	op, err := s.opRepo.Get(ctx, id)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.authz.Require(ctx, op.AccountID, domain.RoleViewer); err != nil {
+		return nil, err
+	}
 
 	return dto.NewOperationDTO(op), nil
 }
 
-func (s *OperationService) List(ctx context.Context) ([]dto.OperationDTO, error) {
+// List returns operations of accounts the current user is a member of.
+func (s *OperationService) List(ctx context.Context) (_ []dto.OperationDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "OperationService.List")
+	defer call.end(&err)
+
+	roles, err := s.authz.Roles(ctx)
+	if err != nil {
+		return nil, err
+	}
 	cats, err := s.opRepo.List(ctx)
 	if err != nil {
 		return nil, err
@@ -41,43 +89,325 @@ func (s *OperationService) List(ctx context.Context) ([]dto.OperationDTO, error)
 
 	resp := make([]dto.OperationDTO, 0, len(cats))
 	for _, c := range cats {
-		resp = append(resp, *dto.NewOperationDTO(&c))
+		if _, ok := roles[c.AccountID]; ok {
+			resp = append(resp, *dto.NewOperationDTO(&c))
+		}
 	}
 	return resp, nil
 }
 
+type FindOperationsRequest struct {
+	// Tags must all be set on an operation for it to match.
+	Tags []string
+}
+
+func (s *OperationService) Find(ctx context.Context, req FindOperationsRequest) (_ []dto.OperationDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "OperationService.Find")
+	defer call.end(&err)
+
+	filter := storage.OperationFilter{}
+	for _, t := range req.Tags {
+		t, err := domain.NormalizeTag(t)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(filter.Tags, t) {
+			filter.Tags = append(filter.Tags, t)
+		}
+	}
+
+	roles, err := s.authz.Roles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ops, err := s.opRepo.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]dto.OperationDTO, 0, len(ops))
+	for _, op := range ops {
+		if _, ok := roles[op.AccountID]; ok {
+			resp = append(resp, *dto.NewOperationDTO(&op))
+		}
+	}
+	return resp, nil
+}
+
+func (s *OperationService) AddTags(ctx context.Context, id uuid.UUID, tags []string) (_ *dto.OperationDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "OperationService.AddTags")
+	defer call.end(&err)
+
+	op, err := s.update(ctx, id, func(_ context.Context, op *domain.Operation) error { return op.AddTags(tags...) })
+	if err != nil {
+		return nil, fmt.Errorf("failed to add tags: %w", err)
+	}
+	return op, nil
+}
+
+func (s *OperationService) RemoveTags(ctx context.Context, id uuid.UUID, tags []string) (_ *dto.OperationDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "OperationService.RemoveTags")
+	defer call.end(&err)
+
+	op, err := s.update(ctx, id, func(_ context.Context, op *domain.Operation) error { return op.RemoveTags(tags...) })
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove tags: %w", err)
+	}
+	return op, nil
+}
+
+type SplitPart struct {
+	CategoryID uuid.UUID
+	Amount     int64
+}
+
+// Split divides the operation between categories, empty parts remove the splits.
+func (s *OperationService) Split(ctx context.Context, id uuid.UUID, parts []SplitPart) (_ *dto.OperationDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "OperationService.Split")
+	defer call.end(&err)
+
+	op, err := s.update(ctx, id, func(ctx context.Context, op *domain.Operation) error {
+		splits := make([]domain.SplitPart, 0, len(parts))
+		for _, p := range parts {
+			cat, err := s.catRepo.Get(ctx, p.CategoryID)
+			if err != nil {
+				return fmt.Errorf("failed to get category: %w", err)
+			}
+			splits = append(splits, domain.SplitPart{Category: cat, Amount: p.Amount})
+		}
+		return op.SetSplits(splits)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to split operation: %w", err)
+	}
+	return op, nil
+}
+
+// update changes the operation without touching the account balance, editors may do it.
+func (s *OperationService) update(ctx context.Context, id uuid.UUID, fn func(context.Context, *domain.Operation) error) (*dto.OperationDTO, error) {
+	var op *domain.Operation
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if op, err = s.opRepo.GetForUpdate(ctx, id); err != nil {
+			return err
+		}
+		if err := s.authz.Require(ctx, op.AccountID, domain.RoleEditor); err != nil {
+			return err
+		}
+
+		if err := fn(ctx, op); err != nil {
+			return err
+		}
+
+		op, err = s.opRepo.Update(ctx, op)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewOperationDTO(op), nil
+}
+
 type ApplyOperationRequest struct {
 	AccountID     uuid.UUID
 	Amount        int64
 	OperationType string
+	Description   string
+	CategoryID    *uuid.UUID
+	Tags          []string
+	// IdempotencyKey makes a retried request return the first result instead of applying it again.
+	IdempotencyKey string
+	// At is when the operation was made, now if it's nil.
+	At *time.Time
+	// AllowDuplicate applies the operation even if it looks like a recent one entered again,
+	// otherwise domain.ErrProbableDuplicate is returned.
+	AllowDuplicate bool
+	// StrictBudget refuses an outcome pushing a budget of its category over the limit
+	// with domain.ErrBudgetExceeded, otherwise such budgets are reported in the response.
+	StrictBudget bool
+}
+
+type ApplyOperationResponse struct {
+	Account   *dto.BankAccountDTO
+	Operation *dto.OperationDTO
+	// DuplicateOf lists recent operations the applied one looks like, set only with AllowDuplicate.
+	DuplicateOf []dto.OperationDTO
+	// BudgetExceeded lists budgets the operation pushed over the limit, set only without StrictBudget.
+	BudgetExceeded []dto.BudgetStatusDTO
 }
 
-func (s *OperationService) ApplyOperation(ctx context.Context, req ApplyOperationRequest) (*dto.BankAccountDTO, error) {
-	acc, err := s.accRepo.Get(ctx, req.AccountID)
+func (s *OperationService) ApplyOperation(ctx context.Context, req ApplyOperationRequest) (_ *ApplyOperationResponse, err error) {
+	ctx, call := startCall(ctx, s.observer, "OperationService.ApplyOperation")
+	defer call.end(&err)
+
+	var resp *ApplyOperationResponse
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = idempotent(ctx, s.idemRepo, req.IdempotencyKey, "operation.apply", req, func(ctx context.Context) (*ApplyOperationResponse, error) {
+			return s.apply(ctx, req)
+		})
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
+	return resp, nil
+}
 
-	op, err := domain.ApplyOperation(acc, domain.OperationType(req.OperationType), req.Amount, "")
+// apply must be called within a transaction.
+func (s *OperationService) apply(ctx context.Context, req ApplyOperationRequest) (*ApplyOperationResponse, error) {
+	if err := s.authz.Require(ctx, req.AccountID, domain.RoleEditor); err != nil {
+		return nil, err
+	}
+	acc, err := s.accRepo.GetForUpdate(ctx, req.AccountID)
 	if err != nil {
 		return nil, err
 	}
 
-	acc, err = s.accRepo.Update(ctx, acc)
+	op, err := s.applyAt(acc, domain.OperationType(req.OperationType), req.Amount, req.Description, req.At)
 	if err != nil {
 		return nil, err
 	}
-	_, err = s.opRepo.Create(ctx, op)
+	if req.CategoryID != nil {
+		cat, err := s.catRepo.Get(ctx, *req.CategoryID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get category: %w", err)
+		}
+		if err := op.SetCategory(cat); err != nil {
+			return nil, err
+		}
+	}
+	if err := op.AddTags(req.Tags...); err != nil {
+		return nil, err
+	}
+
+	var exceeded []dto.BudgetStatusDTO
+	if op.Type == domain.OperationTypeOutcome && req.CategoryID != nil {
+		if exceeded, err = s.budgets.Check(ctx, *req.CategoryID, req.Amount, op.Time); err != nil {
+			return nil, fmt.Errorf("failed to check budgets: %w", err)
+		}
+		if len(exceeded) > 0 && req.StrictBudget {
+			b := exceeded[0]
+			return nil, fmt.Errorf("%w: %s limit %d, would be spent %d", domain.ErrBudgetExceeded, b.Period, b.Limit, b.Spent)
+		}
+	}
+
+	// The account is locked, so a double submit waits here and sees the first operation.
+	duplicates, err := s.findDuplicates(ctx, op)
 	if err != nil {
 		return nil, err
 	}
-	return dto.NewBankAccountDTO(acc), nil
+	if len(duplicates) > 0 && !req.AllowDuplicate {
+		return nil, fmt.Errorf("%w of operation %s made at %s", domain.ErrProbableDuplicate,
+			duplicates[0].ID, duplicates[0].Time.Format(time.DateTime))
+	}
+
+	events := acc.PullEvents()
+	if acc, err = s.accRepo.Update(ctx, acc); err != nil {
+		return nil, err
+	}
+	if op, err = s.opRepo.Create(ctx, op); err != nil {
+		return nil, err
+	}
+	if err := s.eventRepo.Append(ctx, events...); err != nil {
+		return nil, err
+	}
+
+	resp := &ApplyOperationResponse{
+		Account:        dto.NewBankAccountDTO(acc),
+		Operation:      dto.NewOperationDTO(op),
+		BudgetExceeded: exceeded,
+	}
+	for _, d := range duplicates {
+		resp.DuplicateOf = append(resp.DuplicateOf, *dto.NewOperationDTO(&d))
+	}
+	return resp, nil
+}
+
+func (s *OperationService) applyAt(acc *domain.BankAccount, typ domain.OperationType, amount int64, description string, at *time.Time) (*domain.Operation, error) {
+	if at == nil {
+		return domain.ApplyOperation(acc, typ, amount, description)
+	}
+	return domain.ApplyOperationAt(acc, typ, amount, description, *at, s.maxFuture)
+}
+
+// findDuplicates returns operations of the account op is a probable duplicate of.
+func (s *OperationService) findDuplicates(ctx context.Context, op *domain.Operation) ([]domain.Operation, error) {
+	from := op.Time.Add(-domain.DuplicateWindow)
+	to := op.Time.Add(domain.DuplicateWindow)
+	ops, err := s.opRepo.Find(ctx, storage.OperationFilter{
+		AccountID: &op.AccountID,
+		Type:      op.Type,
+		From:      &from,
+		To:        &to,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find operations: %w", err)
+	}
+
+	var duplicates []domain.Operation
+	for _, p := range ops {
+		if op.ProbableDuplicateOf(&p) {
+			duplicates = append(duplicates, p)
+		}
+	}
+	return duplicates, nil
+}
+
+type DuplicatesRequest struct {
+	// AccountID is optional, all accounts are checked when it's nil.
+	AccountID *uuid.UUID
+	Since     *time.Time
+}
+
+// Duplicates lists pairs of existing operations where the later one is a probable duplicate
+// of the earlier one, see domain.Operation.ProbableDuplicateOf.
+func (s *OperationService) Duplicates(ctx context.Context, req DuplicatesRequest) (_ []dto.DuplicatePairDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "OperationService.Duplicates")
+	defer call.end(&err)
+
+	roles, err := s.authz.Roles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	found, err := s.opRepo.Find(ctx, storage.OperationFilter{
+		AccountID: req.AccountID,
+		From:      req.Since,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find operations: %w", err)
+	}
+	var ops []domain.Operation
+	for _, op := range found {
+		if _, ok := roles[op.AccountID]; ok {
+			ops = append(ops, op)
+		}
+	}
+	sort.SliceStable(ops, func(i, j int) bool { return ops[i].Time.Before(ops[j].Time) })
+
+	var resp []dto.DuplicatePairDTO
+	for i := range ops {
+		for j := i - 1; j >= 0 && ops[i].Time.Sub(ops[j].Time) <= domain.DuplicateWindow; j-- {
+			if ops[i].ProbableDuplicateOf(&ops[j]) {
+				resp = append(resp, dto.DuplicatePairDTO{
+					Original:  *dto.NewOperationDTO(&ops[j]),
+					Duplicate: *dto.NewOperationDTO(&ops[i]),
+				})
+			}
+		}
+	}
+	return resp, nil
 }
 
 type TransferRequest struct {
 	FromAccountID uuid.UUID
 	ToAccountID   uuid.UUID
 	Amount        int64
+	// At is when the transfer was made, now if it's nil.
+	At *time.Time
+	// IdempotencyKey makes a retried request return the first result instead of transferring again.
+	IdempotencyKey string
 }
 
 type TransferResponse struct {
@@ -85,29 +415,56 @@ type TransferResponse struct {
 	ToAccount   *dto.BankAccountDTO
 }
 
-func (s *OperationService) Transfer(ctx context.Context, req TransferRequest) (*TransferResponse, error) {
-	from, err := s.accRepo.Get(ctx, req.FromAccountID)
+func (s *OperationService) Transfer(ctx context.Context, req TransferRequest) (_ *TransferResponse, err error) {
+	ctx, call := startCall(ctx, s.observer, "OperationService.Transfer")
+	defer call.end(&err)
+
+	var resp *TransferResponse
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = idempotent(ctx, s.idemRepo, req.IdempotencyKey, "operation.transfer", req, func(ctx context.Context) (*TransferResponse, error) {
+			return s.transfer(ctx, req)
+		})
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	to, err := s.accRepo.Get(ctx, req.ToAccountID)
+	return resp, nil
+}
+
+// transfer must be called within a transaction.
+// transfer moves money out of an account the current user owns to one they can at least see.
+func (s *OperationService) transfer(ctx context.Context, req TransferRequest) (*TransferResponse, error) {
+	if err := s.authz.Require(ctx, req.FromAccountID, domain.RoleOwner); err != nil {
+		return nil, err
+	}
+	if err := s.authz.Require(ctx, req.ToAccountID, domain.RoleViewer); err != nil {
+		return nil, err
+	}
+	from, to, err := s.lockPair(ctx, req.FromAccountID, req.ToAccountID)
 	if err != nil {
 		return nil, err
 	}
 
-	opFrom, err := domain.ApplyOperation(from, domain.OperationTypeOutcome, req.Amount, "")
+	if from.Currency != to.Currency {
+		return nil, domain.ErrCurrencyMismatch
+	}
+
+	opFrom, err := s.applyAt(from, domain.OperationTypeOutcome, req.Amount, "", req.At)
 	if err != nil {
 		return nil, err
 	}
-	opTo, err := domain.ApplyOperation(to, domain.OperationTypeIncome, req.Amount, "")
+	opTo, err := s.applyAt(to, domain.OperationTypeIncome, req.Amount, "", req.At)
 	if err != nil {
 		return nil, err
 	}
 
+	events := append(from.PullEvents(), to.PullEvents()...)
 	if from, err = s.accRepo.Update(ctx, from); err != nil {
 		return nil, err
 	}
-	if from, err = s.accRepo.Update(ctx, from); err != nil {
+	if to, err = s.accRepo.Update(ctx, to); err != nil {
 		return nil, err
 	}
 	if _, err = s.opRepo.Create(ctx, opFrom); err != nil {
@@ -116,9 +473,49 @@ func (s *OperationService) Transfer(ctx context.Context, req TransferRequest) (*
 	if _, err = s.opRepo.Create(ctx, opTo); err != nil {
 		return nil, err
 	}
+	if err := s.eventRepo.Append(ctx, events...); err != nil {
+		return nil, err
+	}
+
+	msg, err := domain.NewOutboxMessage("transfer.completed", dto.TransferDTO{
+		FromAccountID:   from.ID,
+		ToAccountID:     to.ID,
+		FromOperationID: opFrom.ID,
+		ToOperationID:   opTo.ID,
+		Amount:          req.Amount,
+		Currency:        from.Currency,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.outboxRepo.Create(ctx, msg); err != nil {
+		return nil, err
+	}
 
 	return &TransferResponse{
 		FromAccount: dto.NewBankAccountDTO(from),
 		ToAccount:   dto.NewBankAccountDTO(to),
 	}, nil
 }
+
+// lockPair locks both accounts in a stable order, so concurrent opposite transfers don't deadlock.
+func (s *OperationService) lockPair(ctx context.Context, fromID, toID uuid.UUID) (from, to *domain.BankAccount, err error) {
+	if fromID == toID {
+		return nil, nil, domain.ErrSameAccount
+	}
+
+	first, second := fromID, toID
+	if bytes.Compare(first[:], second[:]) > 0 {
+		first, second = second, first
+	}
+
+	locked := make(map[uuid.UUID]*domain.BankAccount, 2)
+	for _, id := range []uuid.UUID{first, second} {
+		acc, err := s.accRepo.GetForUpdate(ctx, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		locked[id] = acc
+	}
+	return locked[fromID], locked[toID], nil
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+// WebhookSender delivers a message to every configured webhook.
+// Delivery is at least once: receivers should deduplicate by message ID.
+type WebhookSender interface {
+	Send(ctx context.Context, msg *domain.OutboxMessage) error
+}
+
+type OutboxService struct {
+	tx         storage.Transactor
+	outboxRepo storage.OutboxRepo
+	sender     WebhookSender
+	observer   CallObserver
+}
+
+func NewOutboxService(tx storage.Transactor, outboxRepo storage.OutboxRepo, sender WebhookSender, observer CallObserver) *OutboxService {
+	return &OutboxService{
+		tx:         tx,
+		outboxRepo: outboxRepo,
+		sender:     sender,
+		observer:   observerOrNop(observer),
+	}
+}
+
+type DispatchRequest struct {
+	BatchSize   int
+	MaxAttempts int
+	// Backoff is the delay before the second attempt, it doubles with every next one.
+	Backoff time.Duration
+	// Lease is how long claimed messages are hidden from other dispatchers while being sent.
+	Lease time.Duration
+}
+
+type DispatchResponse struct {
+	Delivered int `json:"delivered"`
+	Retrying  int `json:"retrying"`
+	Dead      int `json:"dead"`
+}
+
+// Dispatch delivers all due messages. Every batch is claimed for req.Lease in its own short
+// transaction with SKIP LOCKED, so several dispatchers can run at once without sending
+// a message twice. Messages are sent after the claim is committed, so slow webhooks
+// don't hold row locks, and the results are saved in another transaction.
+func (s *OutboxService) Dispatch(ctx context.Context, req DispatchRequest) (_ *DispatchResponse, err error) {
+	ctx, call := startCall(ctx, s.observer, "OutboxService.Dispatch")
+	defer call.end(&err)
+
+	resp := &DispatchResponse{}
+	for {
+		msgs, err := s.claim(ctx, req)
+		if err != nil {
+			return resp, fmt.Errorf("failed to claim outbox messages: %w", err)
+		}
+
+		for i := range msgs {
+			if err := s.sender.Send(ctx, &msgs[i]); err != nil {
+				msgs[i].MarkFailed(err, req.Backoff, req.MaxAttempts)
+			} else {
+				msgs[i].MarkDelivered()
+			}
+		}
+
+		err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+			for _, msg := range msgs {
+				if err := s.outboxRepo.Update(ctx, &msg); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return resp, fmt.Errorf("failed to save outbox results: %w", err)
+		}
+		for _, msg := range msgs {
+			switch msg.Status {
+			case domain.OutboxDelivered:
+				resp.Delivered++
+			case domain.OutboxDead:
+				resp.Dead++
+			default:
+				resp.Retrying++
+			}
+		}
+
+		if len(msgs) < req.BatchSize {
+			return resp, nil
+		}
+	}
+}
+
+// claim takes a batch of due messages for the lease.
+func (s *OutboxService) claim(ctx context.Context, req DispatchRequest) ([]domain.OutboxMessage, error) {
+	var msgs []domain.OutboxMessage
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if msgs, err = s.outboxRepo.ClaimDue(ctx, domain.TimeFunc(), req.BatchSize); err != nil {
+			return err
+		}
+		for i := range msgs {
+			msgs[i].Claim(req.Lease)
+			if err := s.outboxRepo.Update(ctx, &msgs[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+func (s *OutboxService) List(ctx context.Context, status string) (_ []dto.OutboxMessageDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "OutboxService.List")
+	defer call.end(&err)
+
+	msgs, err := s.outboxRepo.ListByStatus(ctx, domain.OutboxStatus(status))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]dto.OutboxMessageDTO, 0, len(msgs))
+	for _, m := range msgs {
+		resp = append(resp, *dto.NewOutboxMessageDTO(&m))
+	}
+	return resp, nil
+}
+
+// Requeue makes a dead message pending again with a fresh set of attempts.
+func (s *OutboxService) Requeue(ctx context.Context, id uuid.UUID) (_ *dto.OutboxMessageDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "OutboxService.Requeue")
+	defer call.end(&err)
+
+	var msg *domain.OutboxMessage
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if msg, err = s.outboxRepo.GetForUpdate(ctx, id); err != nil {
+			return err
+		}
+
+		if err := msg.Requeue(); err != nil {
+			return err
+		}
+
+		return s.outboxRepo.Update(ctx, msg)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to requeue message: %w", err)
+	}
+
+	return dto.NewOutboxMessageDTO(msg), nil
+}
@@ -0,0 +1,232 @@
+package services_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/services"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+	"github.com/sunnyyssh/designing-software-cw1/internal/infrastructure/webhook"
+)
+
+type noTx struct{}
+
+func (noTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// memOutbox keeps messages in memory, claiming pending ones that are due.
+type memOutbox struct {
+	msgs []domain.OutboxMessage
+}
+
+func (r *memOutbox) Create(_ context.Context, msg *domain.OutboxMessage) error {
+	r.msgs = append(r.msgs, *msg)
+	return nil
+}
+
+func (r *memOutbox) GetForUpdate(_ context.Context, id uuid.UUID) (*domain.OutboxMessage, error) {
+	for _, m := range r.msgs {
+		if m.ID == id {
+			return &m, nil
+		}
+	}
+	return nil, storage.ErrNotFound
+}
+
+func (r *memOutbox) ClaimDue(_ context.Context, now time.Time, limit int) ([]domain.OutboxMessage, error) {
+	var due []domain.OutboxMessage
+	for _, m := range r.msgs {
+		if m.Status == domain.OutboxPending && !m.NextAttemptAt.After(now) && len(due) < limit {
+			due = append(due, m)
+		}
+	}
+	return due, nil
+}
+
+func (r *memOutbox) ListByStatus(_ context.Context, status domain.OutboxStatus) ([]domain.OutboxMessage, error) {
+	var msgs []domain.OutboxMessage
+	for _, m := range r.msgs {
+		if m.Status == status {
+			msgs = append(msgs, m)
+		}
+	}
+	return msgs, nil
+}
+
+func (r *memOutbox) Update(_ context.Context, msg *domain.OutboxMessage) error {
+	for i := range r.msgs {
+		if r.msgs[i].ID == msg.ID {
+			r.msgs[i] = *msg
+		}
+	}
+	return nil
+}
+
+// receiver answers 503 to the first failures requests and 200 to the rest.
+func receiver(t *testing.T, failures int32) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+// clock makes domain.TimeFunc return now, which the test moves forward.
+func clock(t *testing.T) *time.Time {
+	t.Helper()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	prev := domain.TimeFunc
+	domain.TimeFunc = func() time.Time { return now }
+	t.Cleanup(func() { domain.TimeFunc = prev })
+	return &now
+}
+
+func newOutbox(t *testing.T, url string) (*services.OutboxService, *memOutbox) {
+	t.Helper()
+	repo := &memOutbox{}
+	msg, err := domain.NewOutboxMessage("operation.applied", map[string]any{"amount": 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Create(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	sender := webhook.NewSender([]string{url}, "secret", time.Second)
+	return services.NewOutboxService(noTx{}, repo, sender, nil), repo
+}
+
+func dispatch(t *testing.T, svc *services.OutboxService, maxAttempts int) services.DispatchResponse {
+	t.Helper()
+	resp, err := svc.Dispatch(context.Background(), services.DispatchRequest{
+		BatchSize:   10,
+		MaxAttempts: maxAttempts,
+		Backoff:     time.Minute,
+		Lease:       time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Dispatch: %v", err)
+	}
+	return *resp
+}
+
+func TestDispatchRetriesWithBackoff(t *testing.T) {
+	now := clock(t)
+	start := *now
+	srv, calls := receiver(t, 2)
+	svc, repo := newOutbox(t, srv.URL)
+
+	if got := dispatch(t, svc, 5); got != (services.DispatchResponse{Retrying: 1}) {
+		t.Fatalf("first dispatch = %+v, want one retrying", got)
+	}
+	if next := repo.msgs[0].NextAttemptAt; !next.Equal(start.Add(time.Minute)) {
+		t.Errorf("next attempt at %s, want after 1m", next)
+	}
+
+	// Not due yet, the receiver must not be called.
+	if got := dispatch(t, svc, 5); got != (services.DispatchResponse{}) {
+		t.Errorf("dispatch before backoff = %+v, want nothing", got)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("receiver called %d times, want 1", calls.Load())
+	}
+
+	*now = now.Add(time.Minute)
+	if got := dispatch(t, svc, 5); got != (services.DispatchResponse{Retrying: 1}) {
+		t.Fatalf("second dispatch = %+v, want one retrying", got)
+	}
+	if next := repo.msgs[0].NextAttemptAt; !next.Equal(now.Add(2 * time.Minute)) {
+		t.Errorf("next attempt at %s, want after doubled backoff of 2m", next)
+	}
+
+	*now = now.Add(2 * time.Minute)
+	if got := dispatch(t, svc, 5); got != (services.DispatchResponse{Delivered: 1}) {
+		t.Fatalf("third dispatch = %+v, want delivered", got)
+	}
+	if msg := repo.msgs[0]; msg.Status != domain.OutboxDelivered || msg.Attempts != 3 || msg.LastError != "" {
+		t.Errorf("message = %+v, want delivered on the 3rd attempt", msg)
+	}
+}
+
+func TestDispatchMovesToDeadAfterMaxAttempts(t *testing.T) {
+	now := clock(t)
+	srv, calls := receiver(t, 1000)
+	svc, repo := newOutbox(t, srv.URL)
+
+	if got := dispatch(t, svc, 2); got != (services.DispatchResponse{Retrying: 1}) {
+		t.Fatalf("first dispatch = %+v, want one retrying", got)
+	}
+	*now = now.Add(time.Minute)
+	if got := dispatch(t, svc, 2); got != (services.DispatchResponse{Dead: 1}) {
+		t.Fatalf("second dispatch = %+v, want dead", got)
+	}
+
+	msg := repo.msgs[0]
+	if msg.Status != domain.OutboxDead || msg.Attempts != 2 || msg.LastError == "" {
+		t.Errorf("message = %+v, want dead after 2 attempts with the last error", msg)
+	}
+
+	// Dead messages are not retried until requeued.
+	*now = now.Add(time.Hour)
+	if got := dispatch(t, svc, 2); got != (services.DispatchResponse{}) {
+		t.Errorf("dispatch of dead message = %+v, want nothing", got)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("receiver called %d times, want 2", calls.Load())
+	}
+}
+
+// trackedTx remembers whether a transaction is open.
+type trackedTx struct {
+	open bool
+}
+
+func (tx *trackedTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx.open = true
+	defer func() { tx.open = false }()
+	return fn(ctx)
+}
+
+// txCheckingSender fails the test if a message is sent within a transaction.
+type txCheckingSender struct {
+	t  *testing.T
+	tx *trackedTx
+}
+
+func (s txCheckingSender) Send(context.Context, *domain.OutboxMessage) error {
+	if s.tx.open {
+		s.t.Error("message is sent within the transaction that claimed it")
+	}
+	return nil
+}
+
+func TestDispatchSendsAfterClaimIsCommitted(t *testing.T) {
+	clock(t)
+	repo := &memOutbox{}
+	msg, err := domain.NewOutboxMessage("operation.applied", map[string]any{"amount": 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Create(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	tx := &trackedTx{}
+	svc := services.NewOutboxService(tx, repo, txCheckingSender{t: t, tx: tx}, nil)
+
+	if got := dispatch(t, svc, 5); got != (services.DispatchResponse{Delivered: 1}) {
+		t.Errorf("dispatch = %+v, want delivered", got)
+	}
+	if repo.msgs[0].Status != domain.OutboxDelivered {
+		t.Errorf("saved status = %s, want delivered", repo.msgs[0].Status)
+	}
+}
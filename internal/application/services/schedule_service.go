@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/auth"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+type ScheduleService struct {
+	tx           storage.Transactor
+	scheduleRepo storage.ScheduleRepo
+	accRepo      storage.BankAccountRepo
+	catRepo      storage.CategoryRepo
+	opSvc        *OperationService
+	authz        *auth.Authorizer
+	observer     CallObserver
+}
+
+func NewScheduleService(
+	tx storage.Transactor,
+	scheduleRepo storage.ScheduleRepo,
+	accRepo storage.BankAccountRepo,
+	catRepo storage.CategoryRepo,
+	opSvc *OperationService,
+	authz *auth.Authorizer,
+	observer CallObserver,
+) *ScheduleService {
+	return &ScheduleService{
+		tx:           tx,
+		scheduleRepo: scheduleRepo,
+		accRepo:      accRepo,
+		catRepo:      catRepo,
+		opSvc:        opSvc,
+		authz:        authz,
+		observer:     observerOrNop(observer),
+	}
+}
+
+type AddScheduleRequest struct {
+	AccountID     uuid.UUID
+	Amount        int64
+	OperationType string
+	Description   string
+	CategoryID    *uuid.UUID
+	Recurrence    string
+	StartAt       time.Time
+}
+
+func (s *ScheduleService) Add(ctx context.Context, req AddScheduleRequest) (_ *dto.ScheduledOperationDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "ScheduleService.Add")
+	defer call.end(&err)
+
+	// Every occurrence is an operation on the account, so scheduling needs the same role.
+	if err := s.authz.Require(ctx, req.AccountID, domain.RoleEditor); err != nil {
+		return nil, err
+	}
+	if _, err := s.accRepo.Get(ctx, req.AccountID); err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	schedule, err := domain.NewScheduledOperation(
+		req.AccountID,
+		domain.OperationType(req.OperationType),
+		req.Amount,
+		req.Description,
+		req.Recurrence,
+		req.StartAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if req.CategoryID != nil {
+		cat, err := s.catRepo.Get(ctx, *req.CategoryID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get category: %w", err)
+		}
+		if err := schedule.SetCategory(cat); err != nil {
+			return nil, err
+		}
+	}
+
+	schedule, err = s.scheduleRepo.Create(ctx, schedule)
+	if err != nil {
+		return nil, err
+	}
+	return dto.NewScheduledOperationDTO(schedule), nil
+}
+
+func (s *ScheduleService) List(ctx context.Context) (_ []dto.ScheduledOperationDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "ScheduleService.List")
+	defer call.end(&err)
+
+	roles, err := s.authz.Roles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := s.scheduleRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]dto.ScheduledOperationDTO, 0, len(schedules))
+	for _, sch := range schedules {
+		if _, ok := roles[sch.AccountID]; ok {
+			resp = append(resp, *dto.NewScheduledOperationDTO(&sch))
+		}
+	}
+	return resp, nil
+}
+
+func (s *ScheduleService) Remove(ctx context.Context, id uuid.UUID) (_ *dto.ScheduledOperationDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "ScheduleService.Remove")
+	defer call.end(&err)
+
+	schedule, err := s.scheduleRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authz.Require(ctx, schedule.AccountID, domain.RoleEditor); err != nil {
+		return nil, err
+	}
+	schedule, err = s.scheduleRepo.Delete(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return dto.NewScheduledOperationDTO(schedule), nil
+}
+
+type RunScheduleResponse struct {
+	Applied []dto.ScheduledOccurrenceDTO `json:"applied"`
+	Failed  []dto.ScheduledOccurrenceDTO `json:"failed"`
+}
+
+// Run materializes every occurrence due until the given time. It is idempotent:
+// each occurrence is applied together with its record in one transaction,
+// so concurrent or repeated runs never apply it twice.
+// A failed occurrence stops its schedule, later ones wait for the next run.
+// Only schedules of accounts the user may edit are run, and the role is checked again
+// when every occurrence is applied.
+func (s *ScheduleService) Run(ctx context.Context, until time.Time) (_ *RunScheduleResponse, err error) {
+	ctx, call := startCall(ctx, s.observer, "ScheduleService.Run")
+	defer call.end(&err)
+
+	roles, err := s.authz.Roles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := s.scheduleRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &RunScheduleResponse{
+		Applied: []dto.ScheduledOccurrenceDTO{},
+		Failed:  []dto.ScheduledOccurrenceDTO{},
+	}
+	for _, sch := range schedules {
+		if role, ok := roles[sch.AccountID]; !ok || !role.Allows(domain.RoleEditor) {
+			continue
+		}
+		last, err := s.scheduleRepo.LastOccurrence(ctx, sch.ID)
+		if err != nil {
+			return nil, err
+		}
+		due, err := sch.Due(last, until)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, at := range due {
+			applied, err := s.materialize(ctx, &sch, at)
+			if errors.Is(err, storage.ErrConflict) {
+				continue
+			}
+			if err != nil {
+				resp.Failed = append(resp.Failed, dto.ScheduledOccurrenceDTO{
+					ScheduleID: sch.ID,
+					OccursAt:   at,
+					Error:      err.Error(),
+				})
+				break
+			}
+			resp.Applied = append(resp.Applied, dto.ScheduledOccurrenceDTO{
+				ScheduleID:     sch.ID,
+				OccursAt:       at,
+				OperationID:    &applied.Operation.ID,
+				BudgetExceeded: applied.BudgetExceeded,
+			})
+		}
+	}
+	return resp, nil
+}
+
+func (s *ScheduleService) materialize(ctx context.Context, sch *domain.ScheduledOperation, at time.Time) (*ApplyOperationResponse, error) {
+	var resp *ApplyOperationResponse
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = s.opSvc.ApplyOperation(ctx, ApplyOperationRequest{
+			AccountID:     sch.AccountID,
+			Amount:        sch.Amount,
+			OperationType: string(sch.Type),
+			Description:   sch.Description,
+			CategoryID:    sch.CategoryID,
+			// Occurrences caught up on late are recorded when they were due, not when run.
+			At: &at,
+			// A schedule is meant to repeat, e.g. two equal subscriptions due the same day.
+			AllowDuplicate: true,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.scheduleRepo.CreateOccurrence(ctx, &domain.ScheduledOccurrence{
+			ScheduleID:  sch.ID,
+			OccursAt:    at,
+			OperationID: resp.Operation.ID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
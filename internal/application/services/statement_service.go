@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/auth"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+type StatementService struct {
+	tx       storage.Transactor
+	accRepo  storage.BankAccountRepo
+	opRepo   storage.OperationRepo
+	catRepo  storage.CategoryRepo
+	authz    *auth.Authorizer
+	observer CallObserver
+}
+
+func NewStatementService(
+	tx storage.Transactor,
+	accRepo storage.BankAccountRepo,
+	opRepo storage.OperationRepo,
+	catRepo storage.CategoryRepo,
+	authz *auth.Authorizer,
+	observer CallObserver,
+) *StatementService {
+	return &StatementService{
+		tx:       tx,
+		accRepo:  accRepo,
+		opRepo:   opRepo,
+		catRepo:  catRepo,
+		authz:    authz,
+		observer: observerOrNop(observer),
+	}
+}
+
+// Generate lists operations of the account from the inclusive from to the exclusive to
+// with the balance after each of them. Every balance change is an operation, so the
+// opening balance is the current one minus everything applied since from.
+func (s *StatementService) Generate(ctx context.Context, accountID uuid.UUID, from, to time.Time) (_ *dto.StatementDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "StatementService.Generate")
+	defer call.end(&err)
+
+	if !to.After(from) {
+		return nil, domain.ErrInvalidPeriod
+	}
+	if err := s.authz.Require(ctx, accountID, domain.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	var (
+		acc *domain.BankAccount
+		ops []domain.Operation
+	)
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// The lock keeps operations from being applied between reading the balance and them.
+		var err error
+		if acc, err = s.accRepo.GetForUpdate(ctx, accountID); err != nil {
+			return err
+		}
+		ops, err = s.opRepo.Find(ctx, storage.OperationFilter{AccountID: &accountID, From: &from})
+		if err != nil {
+			return fmt.Errorf("failed to find operations: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cats, err := s.catRepo.List(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uuid.UUID]string, len(cats))
+	for _, c := range cats {
+		names[c.ID] = c.Name
+	}
+
+	balance := acc.Balance
+	for _, op := range ops {
+		balance -= op.BalanceChange()
+	}
+
+	statement := &dto.StatementDTO{
+		Account:        *dto.NewBankAccountDTO(acc),
+		From:           from,
+		To:             to,
+		OpeningBalance: balance,
+		Lines:          []dto.StatementLineDTO{},
+	}
+	for _, op := range ops {
+		if !op.Time.Before(to) {
+			break
+		}
+		balance += op.BalanceChange()
+		switch op.Type {
+		case domain.OperationTypeIncome:
+			statement.TotalIncome += op.Amount
+		case domain.OperationTypeOutcome:
+			statement.TotalOutcome += op.Amount
+		}
+
+		line := dto.StatementLineDTO{
+			OperationID: op.ID,
+			Time:        op.Time,
+			Type:        string(op.Type),
+			Description: op.Description,
+			Amount:      op.BalanceChange(),
+			Balance:     balance,
+		}
+		if op.CategoryID != nil {
+			line.Category = names[*op.CategoryID]
+		}
+		statement.Lines = append(statement.Lines, line)
+	}
+	statement.ClosingBalance = balance
+
+	return statement, nil
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"sort"
+
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+)
+
+// StatsService reports totals of the tenant for monitoring, they aren't limited to the current user's accounts.
+type StatsService struct {
+	statsRepo storage.StatsRepo
+	observer  CallObserver
+}
+
+func NewStatsService(statsRepo storage.StatsRepo, observer CallObserver) *StatsService {
+	return &StatsService{statsRepo: statsRepo, observer: observerOrNop(observer)}
+}
+
+func (s *StatsService) Stats(ctx context.Context) (_ *dto.StatsDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "StatsService.Stats")
+	defer call.end(&err)
+
+	balances, err := s.statsRepo.BalancesByCurrency(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ops, err := s.statsRepo.OperationsByType(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.StatsDTO{
+		Balances:   make([]dto.CurrencyBalanceDTO, 0, len(balances)),
+		Operations: make([]dto.OperationCountDTO, 0, len(ops)),
+	}
+	for currency, balance := range balances {
+		resp.Balances = append(resp.Balances, dto.CurrencyBalanceDTO{Currency: currency, Balance: balance})
+	}
+	for typ, count := range ops {
+		resp.Operations = append(resp.Operations, dto.OperationCountDTO{Type: string(typ), Count: count})
+	}
+	sort.Slice(resp.Balances, func(i, j int) bool { return resp.Balances[i].Currency < resp.Balances[j].Currency })
+	sort.Slice(resp.Operations, func(i, j int) bool { return resp.Operations[i].Type < resp.Operations[j].Type })
+	return resp, nil
+}
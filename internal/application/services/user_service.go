@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/auth"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+type UserService struct {
+	tx         storage.Transactor
+	userRepo   storage.UserRepo
+	accRepo    storage.BankAccountRepo
+	memberRepo storage.MembershipRepo
+	observer   CallObserver
+}
+
+func NewUserService(
+	tx storage.Transactor,
+	userRepo storage.UserRepo,
+	accRepo storage.BankAccountRepo,
+	memberRepo storage.MembershipRepo,
+	observer CallObserver,
+) *UserService {
+	return &UserService{
+		tx:         tx,
+		userRepo:   userRepo,
+		accRepo:    accRepo,
+		memberRepo: memberRepo,
+		observer:   observerOrNop(observer),
+	}
+}
+
+type UserTokenResponse struct {
+	User *dto.UserDTO
+	// Token is shown only once, it is stored hashed.
+	Token string
+	// Adopted are accounts created before users existed, which the first user of a tenant becomes the owner of.
+	Adopted []dto.BankAccountDTO
+}
+
+// Create adds a user. Anyone with the connection string may do it, as they could do it in the database.
+// The first user of a tenant becomes the owner of all accounts without members. Later users get
+// access to accounts only by being added as members.
+func (s *UserService) Create(ctx context.Context, name string) (_ *UserTokenResponse, err error) {
+	ctx, call := startCall(ctx, s.observer, "UserService.Create")
+	defer call.end(&err)
+
+	user, token, err := domain.NewUser(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var adopted []domain.BankAccount
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		users, err := s.userRepo.List(ctx)
+		if err != nil {
+			return err
+		}
+		if user, err = s.userRepo.Create(ctx, user); err != nil {
+			return err
+		}
+		if len(users) > 0 {
+			return nil
+		}
+		adopted, err = s.adoptUnowned(ctx, user)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrConflict) {
+			return nil, fmt.Errorf("user %q already exists: %w", name, err)
+		}
+		return nil, err
+	}
+
+	resp := &UserTokenResponse{User: dto.NewUserDTO(user), Token: token}
+	for _, a := range adopted {
+		resp.Adopted = append(resp.Adopted, *dto.NewBankAccountDTO(&a))
+	}
+	return resp, nil
+}
+
+// adoptUnowned makes the user the owner of accounts without members. Every account is locked
+// before its members are read, so two first users created at once can't both own it.
+func (s *UserService) adoptUnowned(ctx context.Context, user *domain.User) ([]domain.BankAccount, error) {
+	accs, err := s.accRepo.List(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var adopted []domain.BankAccount
+	for _, a := range accs {
+		if _, err := s.accRepo.GetForUpdate(ctx, a.ID); err != nil {
+			return nil, err
+		}
+		members, err := s.memberRepo.ListByAccount(ctx, a.ID)
+		if err != nil {
+			return nil, err
+		}
+		if len(members) > 0 {
+			continue
+		}
+		if err := s.memberRepo.Save(ctx, &domain.Membership{AccountID: a.ID, UserID: user.ID, Role: domain.RoleOwner}); err != nil {
+			return nil, err
+		}
+		adopted = append(adopted, a)
+	}
+	return adopted, nil
+}
+
+func (s *UserService) List(ctx context.Context) (_ []dto.UserDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "UserService.List")
+	defer call.end(&err)
+
+	users, err := s.userRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]dto.UserDTO, 0, len(users))
+	for _, u := range users {
+		resp = append(resp, *dto.NewUserDTO(&u))
+	}
+	return resp, nil
+}
+
+// Authenticate returns ctx carrying the user the API token belongs to.
+// An empty token leaves ctx unauthenticated, so only commands not touching accounts work.
+func (s *UserService) Authenticate(ctx context.Context, token string) (_ context.Context, err error) {
+	if token == "" {
+		return ctx, nil
+	}
+	// The span isn't kept in the returned ctx, it would be the parent of everything done later.
+	spanCtx, call := startCall(ctx, s.observer, "UserService.Authenticate")
+	defer call.end(&err)
+
+	user, err := s.userRepo.GetByTokenHash(spanCtx, domain.HashToken(token))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, domain.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate: %w", err)
+	}
+	return auth.WithUser(ctx, user), nil
+}
+
+// Me returns the current user.
+func (s *UserService) Me(ctx context.Context) (_ *dto.UserDTO, err error) {
+	ctx, call := startCall(ctx, s.observer, "UserService.Me")
+	defer call.end(&err)
+
+	user, err := auth.CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return dto.NewUserDTO(user), nil
+}
+
+// RotateToken replaces the token of the current user.
+func (s *UserService) RotateToken(ctx context.Context) (_ *UserTokenResponse, err error) {
+	ctx, call := startCall(ctx, s.observer, "UserService.RotateToken")
+	defer call.end(&err)
+
+	user, err := auth.CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rotated := *user
+	token, err := rotated.RotateToken()
+	if err != nil {
+		return nil, err
+	}
+	if user, err = s.userRepo.Update(ctx, &rotated); err != nil {
+		return nil, err
+	}
+	return &UserTokenResponse{User: dto.NewUserDTO(user), Token: token}, nil
+}
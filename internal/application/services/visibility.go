@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/auth"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+// findVisible finds operations of accounts the current user is a member of. If the filter names
+// an account, the user must be able to view it, otherwise domain.ErrForbidden is returned.
+func findVisible(ctx context.Context, authz *auth.Authorizer, opRepo storage.OperationRepo, filter storage.OperationFilter) ([]domain.Operation, error) {
+	if filter.AccountID != nil {
+		if err := authz.Require(ctx, *filter.AccountID, domain.RoleViewer); err != nil {
+			return nil, err
+		}
+		return opRepo.Find(ctx, filter)
+	}
+
+	roles, err := authz.Roles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ops, err := opRepo.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	visible := ops[:0]
+	for _, op := range ops {
+		if _, ok := roles[op.AccountID]; ok {
+			visible = append(visible, op)
+		}
+	}
+	return visible, nil
+}
+
+// auditAccountID returns the account an audit entry concerns. Snapshots of operations and schedules
+// carry it as account_id, categories, budgets and users are shared by the tenant and concern no account.
+func auditAccountID(e *domain.AuditEntry) (uuid.UUID, bool) {
+	switch e.EntityType {
+	case domain.EntityBankAccount, domain.EntityMember:
+		return e.EntityID, true
+	case domain.EntityOperation, domain.EntitySchedule:
+		var snapshot struct {
+			AccountID uuid.UUID `json:"account_id"`
+		}
+		raw := e.After
+		if raw == nil {
+			raw = e.Before
+		}
+		// An unreadable snapshot concerns the zero account, which no one is a member of.
+		_ = json.Unmarshal(raw, &snapshot)
+		return snapshot.AccountID, true
+	default:
+		return uuid.Nil, false
+	}
+}
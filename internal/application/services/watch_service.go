@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/auth"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+type WatchService struct {
+	listener storage.ChangeListener
+	authz    *auth.Authorizer
+	observer CallObserver
+}
+
+func NewWatchService(listener storage.ChangeListener, authz *auth.Authorizer, observer CallObserver) *WatchService {
+	return &WatchService{
+		listener: listener,
+		authz:    authz,
+		observer: observerOrNop(observer),
+	}
+}
+
+type WatchRequest struct {
+	// AccountID limits changes to a single account, nil means all accounts visible to the user.
+	AccountID *uuid.UUID
+}
+
+// Watch calls fn for every committed change until ctx is done or fn fails.
+// Memberships are read once, accounts shared with the user later are not watched.
+func (s *WatchService) Watch(ctx context.Context, req WatchRequest, fn func(*dto.ChangeDTO) error) (err error) {
+	ctx, call := startCall(ctx, s.observer, "WatchService.Watch")
+	defer call.end(&err)
+
+	if req.AccountID != nil {
+		if err := s.authz.Require(ctx, *req.AccountID, domain.RoleViewer); err != nil {
+			return err
+		}
+	}
+	roles, err := s.authz.Roles(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.listener.Listen(ctx, func(c storage.Change) error {
+		if req.AccountID != nil && c.AccountID != *req.AccountID {
+			return nil
+		}
+		if _, ok := roles[c.AccountID]; !ok {
+			return nil
+		}
+		return fn(&dto.ChangeDTO{
+			Kind:      string(c.Kind),
+			AccountID: c.AccountID,
+			Operation: dto.NewOperationDTO(c.Operation),
+			Account:   dto.NewBankAccountDTO(c.Account),
+		})
+	})
+}
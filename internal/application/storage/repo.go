@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
@@ -10,28 +11,169 @@ import (
 
 var (
 	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a unique value is taken.
+	ErrConflict = errors.New("conflict")
+	// ErrConstraint is returned when data breaks a reference or a check of the schema.
+	ErrConstraint = errors.New("constraint violation")
 )
 
+// Transactor runs fn in a single transaction.
+// Repositories called with the ctx passed to fn take part in it.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 type BankAccountRepo interface {
 	Get(ctx context.Context, id uuid.UUID) (*domain.BankAccount, error)
-	List(ctx context.Context) ([]domain.BankAccount, error)
+	// GetForUpdate locks the account until the end of the transaction.
+	GetForUpdate(ctx context.Context, id uuid.UUID) (*domain.BankAccount, error)
+	List(ctx context.Context, includeClosed bool) ([]domain.BankAccount, error)
 	Update(context.Context, *domain.BankAccount) (*domain.BankAccount, error)
 	Create(context.Context, *domain.BankAccount) (*domain.BankAccount, error)
+	// Delete closes the account. Closed accounts are still returned by Get.
 	Delete(ctx context.Context, id uuid.UUID) (*domain.BankAccount, error)
 }
 
 type CategoryRepo interface {
 	Get(ctx context.Context, id uuid.UUID) (*domain.Category, error)
-	List(ctx context.Context) ([]domain.Category, error)
+	// GetForUpdate locks the category, which also blocks new operations referencing it.
+	GetForUpdate(ctx context.Context, id uuid.UUID) (*domain.Category, error)
+	List(ctx context.Context, includeDeleted bool) ([]domain.Category, error)
 	Update(context.Context, *domain.Category) (*domain.Category, error)
 	Create(context.Context, *domain.Category) (*domain.Category, error)
+	// Delete marks the category deleted. Deleted categories are still returned by Get.
 	Delete(ctx context.Context, id uuid.UUID) (*domain.Category, error)
 }
 
+// OperationFilter narrows OperationRepo.Find. Zero fields don't filter anything.
+type OperationFilter struct {
+	AccountID *uuid.UUID
+	Type      domain.OperationType
+	// CategoryIDs match the category of the operation or of any of its splits.
+	CategoryIDs []uuid.UUID
+	// Tags must all be set on the operation.
+	Tags []string
+	// From is inclusive, To is exclusive.
+	From *time.Time
+	To   *time.Time
+}
+
 type OperationRepo interface {
 	Get(ctx context.Context, id uuid.UUID) (*domain.Operation, error)
+	// GetForUpdate locks the operation until the end of the transaction.
+	GetForUpdate(ctx context.Context, id uuid.UUID) (*domain.Operation, error)
 	List(ctx context.Context) ([]domain.Operation, error)
+	Find(ctx context.Context, filter OperationFilter) ([]domain.Operation, error)
+	// CountByCategory counts operations having the category directly or in a split.
+	CountByCategory(ctx context.Context, categoryID uuid.UUID) (int64, error)
+	// ReassignCategory moves all operations and splits of the from category to the to one,
+	// returns how many operations were changed.
+	ReassignCategory(ctx context.Context, from, to uuid.UUID) (int64, error)
 	Update(context.Context, *domain.Operation) (*domain.Operation, error)
 	Create(context.Context, *domain.Operation) (*domain.Operation, error)
 	Delete(ctx context.Context, id uuid.UUID) (*domain.Operation, error)
 }
+
+type BudgetRepo interface {
+	List(ctx context.Context) ([]domain.Budget, error)
+	ListByCategory(ctx context.Context, categoryID uuid.UUID) ([]domain.Budget, error)
+	Update(context.Context, *domain.Budget) (*domain.Budget, error)
+	Create(context.Context, *domain.Budget) (*domain.Budget, error)
+}
+
+type ScheduleRepo interface {
+	Get(ctx context.Context, id uuid.UUID) (*domain.ScheduledOperation, error)
+	List(ctx context.Context) ([]domain.ScheduledOperation, error)
+	Create(context.Context, *domain.ScheduledOperation) (*domain.ScheduledOperation, error)
+	Delete(ctx context.Context, id uuid.UUID) (*domain.ScheduledOperation, error)
+	// ReassignCategory moves scheduled operations of the from category to the to one,
+	// or leaves them without a category if to is nil.
+	ReassignCategory(ctx context.Context, from uuid.UUID, to *uuid.UUID) (int64, error)
+	// LastOccurrence returns nil if nothing was materialized for the schedule yet.
+	LastOccurrence(ctx context.Context, scheduleID uuid.UUID) (*time.Time, error)
+	// CreateOccurrence returns ErrConflict if the occurrence is already recorded.
+	CreateOccurrence(context.Context, *domain.ScheduledOccurrence) error
+	ListOccurrences(ctx context.Context, scheduleID uuid.UUID) ([]domain.ScheduledOccurrence, error)
+}
+
+// AuditFilter narrows AuditRepo.Find. Zero fields don't filter anything.
+type AuditFilter struct {
+	EntityType domain.EntityType
+	EntityID   *uuid.UUID
+	Since      *time.Time
+}
+
+type AuditRepo interface {
+	Create(context.Context, *domain.AuditEntry) error
+	Find(ctx context.Context, filter AuditFilter) ([]domain.AuditEntry, error)
+}
+
+// AccountEventRepo is the append-only event store of bank accounts.
+type AccountEventRepo interface {
+	Append(ctx context.Context, events ...domain.AccountEvent) error
+	// ListByAccount returns events in order, until is inclusive and optional.
+	ListByAccount(ctx context.Context, accountID uuid.UUID, until *time.Time) ([]domain.AccountEvent, error)
+}
+
+type OutboxRepo interface {
+	Create(context.Context, *domain.OutboxMessage) error
+	GetForUpdate(ctx context.Context, id uuid.UUID) (*domain.OutboxMessage, error)
+	// ClaimDue locks up to limit pending messages due by now, skipping ones locked by other dispatchers.
+	ClaimDue(ctx context.Context, now time.Time, limit int) ([]domain.OutboxMessage, error)
+	ListByStatus(ctx context.Context, status domain.OutboxStatus) ([]domain.OutboxMessage, error)
+	Update(context.Context, *domain.OutboxMessage) error
+}
+
+type IdempotencyRepo interface {
+	// Create saves the key unless it exists and reports whether it did. If the key is being
+	// created by a concurrent transaction, Create waits for that transaction to finish.
+	Create(context.Context, *domain.IdempotencyKey) (bool, error)
+	Get(ctx context.Context, userID uuid.UUID, key string) (*domain.IdempotencyKey, error)
+	Update(context.Context, *domain.IdempotencyKey) error
+}
+
+type UserRepo interface {
+	// Create returns ErrConflict if the name is taken.
+	Create(context.Context, *domain.User) (*domain.User, error)
+	GetByName(ctx context.Context, name string) (*domain.User, error)
+	GetByTokenHash(ctx context.Context, hash string) (*domain.User, error)
+	List(ctx context.Context) ([]domain.User, error)
+	Update(context.Context, *domain.User) (*domain.User, error)
+}
+
+type MembershipRepo interface {
+	Get(ctx context.Context, accountID, userID uuid.UUID) (*domain.Membership, error)
+	ListByAccount(ctx context.Context, accountID uuid.UUID) ([]domain.Membership, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]domain.Membership, error)
+	// Save adds the member or changes its role.
+	Save(context.Context, *domain.Membership) error
+	Delete(ctx context.Context, accountID, userID uuid.UUID) error
+}
+
+// StatsRepo aggregates data of the whole tenant, regardless of account members.
+type StatsRepo interface {
+	// BalancesByCurrency sums balances of open accounts.
+	BalancesByCurrency(ctx context.Context) (map[string]int64, error)
+	OperationsByType(ctx context.Context) (map[domain.OperationType]int64, error)
+}
+
+type ChangeKind string
+
+const (
+	ChangeOperation ChangeKind = "operation"
+	ChangeAccount   ChangeKind = "account"
+)
+
+// Change is a new operation or an updated account, only the field of its kind is set.
+type Change struct {
+	Kind      ChangeKind
+	AccountID uuid.UUID
+	Operation *domain.Operation
+	Account   *domain.BankAccount
+}
+
+// ChangeListener streams changes committed by any client of the database.
+type ChangeListener interface {
+	// Listen blocks calling fn for every change until ctx is done or fn fails.
+	Listen(ctx context.Context, fn func(Change) error) error
+}
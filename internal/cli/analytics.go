@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/services"
+)
+
+type AnalyticsService interface {
+	CategoryTotals(ctx context.Context, req services.TotalsRequest) ([]dto.CategoryTotalDTO, error)
+	TagTotals(ctx context.Context, req services.TotalsRequest) ([]dto.TagTotalDTO, error)
+	Anomalies(ctx context.Context, req services.AnomaliesRequest) ([]dto.AnomalyDTO, error)
+}
+
+func Analytics(svc AnalyticsService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "analytics",
+		Short: "Spending and income reports",
+	}
+	cmd.AddCommand(
+		categoryTotals(svc),
+		tagTotals(svc),
+		anomalies(svc),
+	)
+	return cmd
+}
+
+// totalsFlags are the report filters shared by analytics commands.
+type totalsFlags struct {
+	accIDStr string
+	fromStr  string
+	toStr    string
+}
+
+func (f *totalsFlags) bind(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.accIDStr, "acc-id", "i", "", "Account ID (default all accounts)")
+	cmd.Flags().StringVar(&f.fromStr, "from", "", "Start of the period, inclusive")
+	cmd.Flags().StringVar(&f.toStr, "to", "", "End of the period, exclusive")
+}
+
+func (f *totalsFlags) request() (services.TotalsRequest, error) {
+	accID, err := parseOptionalID(f.accIDStr)
+	if err != nil {
+		return services.TotalsRequest{}, fmt.Errorf("invalid account ID: %w", err)
+	}
+	req := services.TotalsRequest{AccountID: accID}
+	if f.fromStr != "" {
+		from, err := parseTime(f.fromStr)
+		if err != nil {
+			return services.TotalsRequest{}, err
+		}
+		req.From = &from
+	}
+	if f.toStr != "" {
+		to, err := parseTime(f.toStr)
+		if err != nil {
+			return services.TotalsRequest{}, err
+		}
+		req.To = &to
+	}
+	return req, nil
+}
+
+func categoryTotals(svc AnalyticsService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "categories",
+		Short: "Show totals per category, subcategories rolled up into parents",
+	}
+
+	var flags totalsFlags
+	flags.bind(cmd)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		req, err := flags.request()
+		if err != nil {
+			return err
+		}
+
+		totals, err := svc.CategoryTotals(cmd.Context(), req)
+		if err != nil {
+			return fmt.Errorf("failed to get category totals: %w", err)
+		}
+
+		cmd.Println("Category totals:")
+		Print(cmd, totals)
+		return nil
+	}
+
+	return cmd
+}
+
+func tagTotals(svc AnalyticsService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tags",
+		Short: "Show income and outcome totals per tag",
+	}
+
+	var flags totalsFlags
+	flags.bind(cmd)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		req, err := flags.request()
+		if err != nil {
+			return err
+		}
+
+		totals, err := svc.TagTotals(cmd.Context(), req)
+		if err != nil {
+			return fmt.Errorf("failed to get tag totals: %w", err)
+		}
+
+		cmd.Println("Tag totals:")
+		Print(cmd, totals)
+		return nil
+	}
+
+	return cmd
+}
+
+func anomalies(svc AnalyticsService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "anomalies",
+		Short: "List suspicious operations with the reason they stand out",
+		Long: `List operations made since --since that stand out from the 90 days before them:
+amounts over 3x the median of the last operations in the same category and account,
+hours the account is never used at, and repeats of the same amount and a similar
+description within 5 minutes.`,
+	}
+
+	var (
+		accIDStr string
+		sinceStr string
+	)
+	cmd.Flags().StringVarP(&accIDStr, "acc-id", "i", "", "Account ID (default all accounts)")
+	cmd.Flags().StringVar(&sinceStr, "since", "", "Check operations made since this time (default 7 days ago)")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		accID, err := parseOptionalID(accIDStr)
+		if err != nil {
+			return fmt.Errorf("invalid account ID: %w", err)
+		}
+		since := time.Now().AddDate(0, 0, -7)
+		if sinceStr != "" {
+			if since, err = parseTime(sinceStr); err != nil {
+				return err
+			}
+		}
+
+		found, err := svc.Anomalies(cmd.Context(), services.AnomaliesRequest{AccountID: accID, Since: since})
+		if err != nil {
+			return fmt.Errorf("failed to find anomalies: %w", err)
+		}
+
+		cmd.Println("Suspicious operations:")
+		Print(cmd, found)
+		return nil
+	}
+
+	return cmd
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/services"
+)
+
+type AuditService interface {
+	List(ctx context.Context, req services.ListAuditRequest) ([]dto.AuditEntryDTO, error)
+}
+
+func Audit(svc AuditService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "History of all state changes",
+	}
+	cmd.AddCommand(
+		listAudit(svc),
+	)
+	return cmd
+}
+
+func listAudit(svc AuditService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List audit log entries, oldest first",
+		Example: `  bankcli audit list --entity account --since 2026-01-01
+  bankcli audit list --entity <account-id>`,
+	}
+
+	var (
+		entity   string
+		sinceStr string
+	)
+	cmd.Flags().StringVarP(&entity, "entity", "e", "", "Entity ID or type (account/category/operation/budget/schedule/member/user)")
+	cmd.Flags().StringVar(&sinceStr, "since", "", "Only entries at or after this time")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		req := services.ListAuditRequest{Entity: entity}
+		if sinceStr != "" {
+			since, err := parseTime(sinceStr)
+			if err != nil {
+				return err
+			}
+			req.Since = &since
+		}
+
+		entries, err := svc.List(cmd.Context(), req)
+		if err != nil {
+			return fmt.Errorf("failed to list audit log: %w", err)
+		}
+
+		cmd.Println("Audit log:")
+		Print(cmd, entries)
+		return nil
+	}
+
+	return cmd
+}
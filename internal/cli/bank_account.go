@@ -3,23 +3,36 @@ package cli
 import (
 	"context"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
 	"github.com/sunnyyssh/designing-software-cw1/internal/application/services"
+	"github.com/sunnyyssh/designing-software-cw1/internal/settings"
 )
 
 type BankAccountService interface {
 	Get(ctx context.Context, id uuid.UUID) (*dto.BankAccountDTO, error)
-	List(ctx context.Context) ([]dto.BankAccountDTO, error)
-	CreateAccount(ctx context.Context, name string) (*dto.BankAccountDTO, error)
+	List(ctx context.Context, includeClosed bool) ([]dto.BankAccountDTO, error)
+	CreateAccount(ctx context.Context, req services.CreateAccountRequest) (*dto.BankAccountDTO, error)
 	Block(ctx context.Context, id uuid.UUID) (*dto.BankAccountDTO, error)
 	Unblock(ctx context.Context, id uuid.UUID) (*dto.BankAccountDTO, error)
 	Delete(ctx context.Context, id uuid.UUID) (*dto.BankAccountDTO, error)
+	Restore(ctx context.Context, id uuid.UUID) (*dto.BankAccountDTO, error)
+	SetOverdraftLimit(ctx context.Context, id uuid.UUID, limit int64) (*dto.BankAccountDTO, error)
+	History(ctx context.Context, id uuid.UUID, at time.Time) (*dto.AccountHistoryDTO, error)
+	Members(ctx context.Context, id uuid.UUID) ([]dto.MemberDTO, error)
+	SetMember(ctx context.Context, req services.SetMemberRequest) error
+	RemoveMember(ctx context.Context, accountID uuid.UUID, userName string) error
 }
 
-func Account(svc *services.BankAccountService) *cobra.Command {
+type StatementService interface {
+	Generate(ctx context.Context, accountID uuid.UUID, from, to time.Time) (*dto.StatementDTO, error)
+}
+
+func Account(svc *services.BankAccountService, statementSvc StatementService, set *settings.Settings) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "account",
 		Short: "Operations connected to the bank account",
@@ -27,10 +40,15 @@ func Account(svc *services.BankAccountService) *cobra.Command {
 	cmd.AddCommand(
 		getAccount(svc),
 		listAccounts(svc),
-		createAccount(svc),
+		createAccount(svc, set.DefaultCurrency),
 		blockAccount(svc),
 		unblockAccount(svc),
 		deleteAccount(svc),
+		restoreAccount(svc),
+		setAccountLimit(svc),
+		accountHistory(svc),
+		accountStatement(statementSvc),
+		accountMember(svc),
 	)
 	return cmd
 }
@@ -57,7 +75,7 @@ func getAccount(svc BankAccountService) *cobra.Command {
 		}
 
 		cmd.Println("Account details:")
-		PrettyJSON(cmd, account)
+		Print(cmd, account)
 		return nil
 	}
 
@@ -70,36 +88,52 @@ func listAccounts(svc BankAccountService) *cobra.Command {
 		Short: "List all bank accounts",
 	}
 
+	var includeClosed bool
+	cmd.Flags().BoolVar(&includeClosed, "include-closed", false, "List closed accounts too")
+
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		accounts, err := svc.List(cmd.Context())
+		accounts, err := svc.List(cmd.Context(), includeClosed)
 		if err != nil {
 			return fmt.Errorf("failed to list accounts: %w", err)
 		}
 
 		cmd.Println("Accounts:")
-		PrettyJSON(cmd, accounts)
+		Print(cmd, accounts)
 		return nil
 	}
 
 	return cmd
 }
 
-func createAccount(svc *services.BankAccountService) *cobra.Command {
+func createAccount(svc *services.BankAccountService, defaultCurrency string) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "create",
 		Short: "Create bank account",
 	}
 
-	var name string
+	var (
+		name      string
+		currency  string
+		typ       string
+		overdraft int64
+	)
 	cmd.PersistentFlags().StringVarP(&name, "name", "n", "", "The name of account")
+	cmd.PersistentFlags().StringVarP(&currency, "currency", "c", defaultCurrency, "Currency code of account")
+	cmd.PersistentFlags().StringVarP(&typ, "type", "t", "debit", "Account type (debit/credit/savings)")
+	cmd.PersistentFlags().Int64Var(&overdraft, "overdraft", 0, "How far below zero the balance may go")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		acc, err := svc.CreateAccount(cmd.Context(), name)
+		acc, err := svc.CreateAccount(cmd.Context(), services.CreateAccountRequest{
+			Name:           name,
+			Currency:       currency,
+			Type:           typ,
+			OverdraftLimit: overdraft,
+		})
 		if err != nil {
 			return err
 		}
 		cmd.Println(`Created an account:`)
-		PrettyJSON(cmd, acc)
+		Print(cmd, acc)
 		return nil
 	}
 	return cmd
@@ -126,7 +160,7 @@ func blockAccount(svc *services.BankAccountService) *cobra.Command {
 		}
 
 		cmd.Println(`Blocked an account:`)
-		PrettyJSON(cmd, acc)
+		Print(cmd, acc)
 		return nil
 	}
 	return cmd
@@ -153,7 +187,7 @@ func unblockAccount(svc *services.BankAccountService) *cobra.Command {
 		}
 
 		cmd.Println(`Unblocked an account:`)
-		PrettyJSON(cmd, acc)
+		Print(cmd, acc)
 		return nil
 	}
 	return cmd
@@ -162,7 +196,7 @@ func unblockAccount(svc *services.BankAccountService) *cobra.Command {
 func deleteAccount(svc *services.BankAccountService) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "delete",
-		Short: "Delete bank account",
+		Short: "Close an empty bank account, it stays available by get and history",
 	}
 
 	var idStr string
@@ -180,7 +214,273 @@ func deleteAccount(svc *services.BankAccountService) *cobra.Command {
 		}
 
 		cmd.Println(`Deleted an account:`)
-		PrettyJSON(cmd, acc)
+		Print(cmd, acc)
+		return nil
+	}
+	return cmd
+}
+
+func restoreAccount(svc BankAccountService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Reopen a closed bank account",
+	}
+
+	var idStr string
+	cmd.Flags().StringVarP(&idStr, "id", "i", "", "The ID of account")
+	cmd.MarkFlagRequired("id")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return err
+		}
+
+		acc, err := svc.Restore(cmd.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		cmd.Println(`Restored an account:`)
+		Print(cmd, acc)
+		return nil
+	}
+	return cmd
+}
+
+func setAccountLimit(svc BankAccountService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set-limit",
+		Short: "Set overdraft limit of bank account",
+	}
+
+	var (
+		idStr string
+		limit int64
+	)
+	cmd.Flags().StringVarP(&idStr, "id", "i", "", "The ID of account")
+	cmd.Flags().Int64VarP(&limit, "limit", "l", 0, "Overdraft limit")
+	cmd.MarkFlagRequired("id")
+	cmd.MarkFlagRequired("limit")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return err
+		}
+
+		acc, err := svc.SetOverdraftLimit(cmd.Context(), id, limit)
+		if err != nil {
+			return err
+		}
+
+		cmd.Println(`Changed overdraft limit of an account:`)
+		Print(cmd, acc)
+		return nil
+	}
+	return cmd
+}
+
+func accountHistory(svc BankAccountService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show the state of bank account at a point in time, rebuilt from its events",
+	}
+
+	var (
+		idStr string
+		atStr string
+	)
+	cmd.Flags().StringVarP(&idStr, "id", "i", "", "The ID of account")
+	cmd.Flags().StringVar(&atStr, "at", "", "Point in time (default now)")
+	cmd.MarkFlagRequired("id")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return err
+		}
+		at := time.Now()
+		if atStr != "" {
+			if at, err = parseTime(atStr); err != nil {
+				return err
+			}
+		}
+
+		history, err := svc.History(cmd.Context(), id, at)
+		if err != nil {
+			return fmt.Errorf("failed to get account history: %w", err)
+		}
+
+		cmd.Println(`Account history:`)
+		Print(cmd, history)
+		return nil
+	}
+	return cmd
+}
+
+func accountStatement(svc StatementService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "statement",
+		Short: "Print an account statement with running balances for a period",
+		Example: `  bankcli account statement -i <acc-id> --from 2026-03-01 --to 2026-04-01
+  bankcli account statement -i <acc-id> --format html > statement.html`,
+	}
+
+	var (
+		idStr   string
+		fromStr string
+		toStr   string
+		format  string
+	)
+	cmd.Flags().StringVarP(&idStr, "id", "i", "", "The ID of account")
+	cmd.Flags().StringVar(&fromStr, "from", "", "Start of the period, inclusive (default start of this month)")
+	cmd.Flags().StringVar(&toStr, "to", "", "End of the period, exclusive (default start of next month)")
+	cmd.Flags().StringVarP(&format, "format", "f", StatementText, fmt.Sprintf("Statement format %v", StatementFormats))
+	cmd.MarkFlagRequired("id")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(StatementFormats, format) {
+			return fmt.Errorf("unknown statement format %q, expected one of %v", format, StatementFormats)
+		}
+
+		now := time.Now()
+		from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+		if fromStr != "" {
+			if from, err = parseTime(fromStr); err != nil {
+				return err
+			}
+		}
+		to := from.AddDate(0, 1, 0)
+		if toStr != "" {
+			if to, err = parseTime(toStr); err != nil {
+				return err
+			}
+		}
+
+		statement, err := svc.Generate(cmd.Context(), id, from, to)
+		if err != nil {
+			return fmt.Errorf("failed to generate statement: %w", err)
+		}
+
+		return renderStatement(cmd.OutOrStdout(), format, statement)
+	}
+	return cmd
+}
+
+func accountMember(svc BankAccountService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "member",
+		Short: "Share an account with other users",
+		Long: `Members of an account have one of the roles:
+  viewer  sees the account and its operations
+  editor  also applies incomes and outcomes and edits operations
+  owner   also transfers money out, changes, closes and shares the account`,
+	}
+	cmd.AddCommand(
+		listMembers(svc),
+		setMember(svc),
+		removeMember(svc),
+	)
+	return cmd
+}
+
+func listMembers(svc BankAccountService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List members of an account",
+	}
+
+	var idStr string
+	cmd.Flags().StringVarP(&idStr, "id", "i", "", "The ID of account")
+	cmd.MarkFlagRequired("id")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return err
+		}
+
+		members, err := svc.Members(cmd.Context(), id)
+		if err != nil {
+			return fmt.Errorf("failed to list members: %w", err)
+		}
+
+		cmd.Println("Members:")
+		Print(cmd, members)
+		return nil
+	}
+	return cmd
+}
+
+func setMember(svc BankAccountService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set",
+		Short: "Add a member or change its role, only owners can",
+	}
+
+	var (
+		idStr string
+		user  string
+		role  string
+	)
+	cmd.Flags().StringVarP(&idStr, "id", "i", "", "The ID of account")
+	cmd.Flags().StringVarP(&user, "user", "u", "", "User name")
+	cmd.Flags().StringVarP(&role, "role", "r", "viewer", "Role (owner/editor/viewer)")
+	cmd.MarkFlagRequired("id")
+	cmd.MarkFlagRequired("user")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return err
+		}
+
+		err = svc.SetMember(cmd.Context(), services.SetMemberRequest{
+			AccountID: id,
+			UserName:  user,
+			Role:      role,
+		})
+		if err != nil {
+			return err
+		}
+
+		cmd.Printf("%s is now %s of the account\n", user, role)
+		return nil
+	}
+	return cmd
+}
+
+func removeMember(svc BankAccountService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remove",
+		Short: "Revoke access of a member, owners can remove anyone and others only themselves",
+	}
+
+	var (
+		idStr string
+		user  string
+	)
+	cmd.Flags().StringVarP(&idStr, "id", "i", "", "The ID of account")
+	cmd.Flags().StringVarP(&user, "user", "u", "", "User name")
+	cmd.MarkFlagRequired("id")
+	cmd.MarkFlagRequired("user")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return err
+		}
+
+		if err := svc.RemoveMember(cmd.Context(), id, user); err != nil {
+			return err
+		}
+
+		cmd.Printf("%s is no longer a member of the account\n", user)
 		return nil
 	}
 	return cmd
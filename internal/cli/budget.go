@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+)
+
+type BudgetService interface {
+	Set(ctx context.Context, categoryID uuid.UUID, period string, limit int64) (*dto.BudgetDTO, error)
+	List(ctx context.Context) ([]dto.BudgetDTO, error)
+	Status(ctx context.Context) ([]dto.BudgetStatusDTO, error)
+}
+
+func Budget(svc BudgetService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "budget",
+		Short: "Spending limits per category",
+	}
+	cmd.AddCommand(
+		setBudget(svc),
+		listBudgets(svc),
+		budgetStatus(svc),
+	)
+	return cmd
+}
+
+func setBudget(svc BudgetService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set",
+		Short: "Set a spending limit for an outcome category",
+	}
+
+	var (
+		categoryIDStr string
+		period        string
+		limit         int64
+	)
+	cmd.Flags().StringVarP(&categoryIDStr, "category", "c", "", "Category ID")
+	cmd.Flags().StringVarP(&period, "period", "p", "monthly", "Budget period (monthly/weekly)")
+	cmd.Flags().Int64VarP(&limit, "limit", "l", 0, "Limit amount for the period")
+	cmd.MarkFlagRequired("category")
+	cmd.MarkFlagRequired("limit")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		categoryID, err := uuid.Parse(categoryIDStr)
+		if err != nil {
+			return fmt.Errorf("invalid category ID: %w", err)
+		}
+
+		budget, err := svc.Set(cmd.Context(), categoryID, period, limit)
+		if err != nil {
+			return fmt.Errorf("failed to set budget: %w", err)
+		}
+
+		cmd.Println("Budget:")
+		Print(cmd, budget)
+		return nil
+	}
+
+	return cmd
+}
+
+func listBudgets(svc BudgetService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List all budgets",
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		budgets, err := svc.List(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to list budgets: %w", err)
+		}
+
+		cmd.Println("Budgets:")
+		Print(cmd, budgets)
+		return nil
+	}
+
+	return cmd
+}
+
+func budgetStatus(svc BudgetService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show spent vs. limit for the current period of every budget",
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		statuses, err := svc.Status(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to get budgets status: %w", err)
+		}
+
+		cmd.Println("Budgets status:")
+		Print(cmd, statuses)
+		return nil
+	}
+
+	return cmd
+}
@@ -7,13 +7,19 @@ import (
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/services"
 )
 
 type CategoryService interface {
 	Get(ctx context.Context, id uuid.UUID) (*dto.CategoryDTO, error)
-	Create(ctx context.Context, typ string, name string) (*dto.CategoryDTO, error)
-	List(ctx context.Context) ([]dto.CategoryDTO, error)
-	Delete(ctx context.Context, id uuid.UUID) (*dto.CategoryDTO, error)
+	Create(ctx context.Context, typ string, name string, parentID *uuid.UUID) (*dto.CategoryDTO, error)
+	List(ctx context.Context, includeDeleted bool) ([]dto.CategoryDTO, error)
+	Tree(ctx context.Context) ([]dto.CategoryTreeDTO, error)
+	Move(ctx context.Context, id uuid.UUID, parentID *uuid.UUID) (*dto.CategoryDTO, error)
+	Rename(ctx context.Context, id uuid.UUID, name string) (*dto.CategoryDTO, error)
+	Merge(ctx context.Context, from, into uuid.UUID) (*services.RemoveCategoryResponse, error)
+	Delete(ctx context.Context, req services.DeleteCategoryRequest) (*services.RemoveCategoryResponse, error)
+	Restore(ctx context.Context, id uuid.UUID) (*dto.CategoryDTO, error)
 }
 
 func Category(svc CategoryService) *cobra.Command {
@@ -25,7 +31,12 @@ func Category(svc CategoryService) *cobra.Command {
 		getCategory(svc),
 		createCategory(svc),
 		listCategories(svc),
+		categoryTree(svc),
+		moveCategory(svc),
+		renameCategory(svc),
+		mergeCategories(svc),
 		deleteCategory(svc),
+		restoreCategory(svc),
 	)
 	return cmd
 }
@@ -52,7 +63,7 @@ func getCategory(svc CategoryService) *cobra.Command {
 		}
 
 		cmd.Println("Category details:")
-		PrettyJSON(cmd, category)
+		Print(cmd, category)
 		return nil
 	}
 
@@ -63,25 +74,33 @@ func createCategory(svc CategoryService) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "create",
 		Short: "Create a new category",
+		Example: `  bankcli category create -t outcome -n Food
+  bankcli category create -p <food-id> -n Restaurants`,
 	}
 
 	var (
-		typ  string
-		name string
+		typ         string
+		name        string
+		parentIDStr string
 	)
-	cmd.Flags().StringVarP(&typ, "type", "t", "", "Category type (income/outcome)")
+	cmd.Flags().StringVarP(&typ, "type", "t", "", "Category type (income/outcome), inherited from the parent if omitted")
 	cmd.Flags().StringVarP(&name, "name", "n", "", "Category name")
-	cmd.MarkFlagRequired("type")
+	cmd.Flags().StringVarP(&parentIDStr, "parent", "p", "", "Parent category ID")
 	cmd.MarkFlagRequired("name")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		category, err := svc.Create(cmd.Context(), typ, name)
+		parentID, err := parseOptionalID(parentIDStr)
+		if err != nil {
+			return fmt.Errorf("invalid parent category ID: %w", err)
+		}
+
+		category, err := svc.Create(cmd.Context(), typ, name, parentID)
 		if err != nil {
 			return fmt.Errorf("failed to create category: %w", err)
 		}
 
 		cmd.Println("Created category:")
-		PrettyJSON(cmd, category)
+		Print(cmd, category)
 		return nil
 	}
 
@@ -94,14 +113,146 @@ func listCategories(svc CategoryService) *cobra.Command {
 		Short: "List all categories",
 	}
 
+	var includeDeleted bool
+	cmd.Flags().BoolVar(&includeDeleted, "include-deleted", false, "List deleted categories too")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		categories, err := svc.List(cmd.Context(), includeDeleted)
+		if err != nil {
+			return fmt.Errorf("failed to list categories: %w", err)
+		}
+
+		cmd.Println("Categories:")
+		Print(cmd, categories)
+		return nil
+	}
+
+	return cmd
+}
+
+func categoryTree(svc CategoryService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tree",
+		Short: "Show categories as a tree",
+	}
+
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		categories, err := svc.List(cmd.Context())
+		tree, err := svc.Tree(cmd.Context())
 		if err != nil {
 			return fmt.Errorf("failed to list categories: %w", err)
 		}
 
 		cmd.Println("Categories:")
-		PrettyJSON(cmd, categories)
+		Print(cmd, tree)
+		return nil
+	}
+
+	return cmd
+}
+
+func moveCategory(svc CategoryService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "move",
+		Short: "Move a category under another one, or to the top level without --parent",
+	}
+
+	var (
+		categoryIDStr string
+		parentIDStr   string
+	)
+	cmd.Flags().StringVarP(&categoryIDStr, "id", "i", "", "Category ID")
+	cmd.Flags().StringVarP(&parentIDStr, "parent", "p", "", "New parent category ID")
+	cmd.MarkFlagRequired("id")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		categoryID, err := uuid.Parse(categoryIDStr)
+		if err != nil {
+			return fmt.Errorf("invalid category ID: %w", err)
+		}
+		parentID, err := parseOptionalID(parentIDStr)
+		if err != nil {
+			return fmt.Errorf("invalid parent category ID: %w", err)
+		}
+
+		category, err := svc.Move(cmd.Context(), categoryID, parentID)
+		if err != nil {
+			return fmt.Errorf("failed to move category: %w", err)
+		}
+
+		cmd.Println("Moved category:")
+		Print(cmd, category)
+		return nil
+	}
+
+	return cmd
+}
+
+func renameCategory(svc CategoryService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rename",
+		Short: "Rename a category",
+	}
+
+	var (
+		categoryIDStr string
+		name          string
+	)
+	cmd.Flags().StringVarP(&categoryIDStr, "id", "i", "", "Category ID")
+	cmd.Flags().StringVarP(&name, "name", "n", "", "New category name")
+	cmd.MarkFlagRequired("id")
+	cmd.MarkFlagRequired("name")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		categoryID, err := uuid.Parse(categoryIDStr)
+		if err != nil {
+			return fmt.Errorf("invalid category ID: %w", err)
+		}
+
+		category, err := svc.Rename(cmd.Context(), categoryID, name)
+		if err != nil {
+			return fmt.Errorf("failed to rename category: %w", err)
+		}
+
+		cmd.Println("Renamed category:")
+		Print(cmd, category)
+		return nil
+	}
+
+	return cmd
+}
+
+func mergeCategories(svc CategoryService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "merge",
+		Short: "Move all operations and subcategories of one category into another and delete it",
+	}
+
+	var (
+		fromIDStr string
+		intoIDStr string
+	)
+	cmd.Flags().StringVar(&fromIDStr, "from", "", "ID of the category to merge and delete")
+	cmd.Flags().StringVar(&intoIDStr, "into", "", "ID of the category to keep")
+	cmd.MarkFlagRequired("from")
+	cmd.MarkFlagRequired("into")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		fromID, err := uuid.Parse(fromIDStr)
+		if err != nil {
+			return fmt.Errorf("invalid category ID: %w", err)
+		}
+		intoID, err := uuid.Parse(intoIDStr)
+		if err != nil {
+			return fmt.Errorf("invalid category ID: %w", err)
+		}
+
+		resp, err := svc.Merge(cmd.Context(), fromID, intoID)
+		if err != nil {
+			return fmt.Errorf("failed to merge categories: %w", err)
+		}
+
+		cmd.Println("Merged categories:")
+		Print(cmd, resp)
 		return nil
 	}
 
@@ -112,10 +263,22 @@ func deleteCategory(svc CategoryService) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "delete",
 		Short: "Delete a category by its ID",
+		Long: `Delete a category by its ID.
+A category used by operations is deleted only with --reassign-to, which moves
+them to another category, or with --force, which leaves them with the deleted
+category, so past reports don't change. Scheduled operations are left
+uncategorized and subcategories are moved to the parent of the deleted category.
+A deleted category is hidden from listings and can be brought back with restore.`,
 	}
 
-	var categoryIDStr string
+	var (
+		categoryIDStr string
+		reassignToStr string
+		force         bool
+	)
 	cmd.Flags().StringVarP(&categoryIDStr, "id", "i", "", "Category ID")
+	cmd.Flags().StringVar(&reassignToStr, "reassign-to", "", "Move operations to this category before deleting")
+	cmd.Flags().BoolVar(&force, "force", false, "Delete even if operations reference the category")
 	cmd.MarkFlagRequired("id")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
@@ -123,14 +286,51 @@ func deleteCategory(svc CategoryService) *cobra.Command {
 		if err != nil {
 			return fmt.Errorf("invalid category ID: %w", err)
 		}
+		reassignTo, err := parseOptionalID(reassignToStr)
+		if err != nil {
+			return fmt.Errorf("invalid category ID: %w", err)
+		}
 
-		category, err := svc.Delete(cmd.Context(), categoryID)
+		resp, err := svc.Delete(cmd.Context(), services.DeleteCategoryRequest{
+			ID:         categoryID,
+			ReassignTo: reassignTo,
+			Force:      force,
+		})
 		if err != nil {
 			return fmt.Errorf("failed to delete category: %w", err)
 		}
 
 		cmd.Println("Deleted category:")
-		PrettyJSON(cmd, category)
+		Print(cmd, resp)
+		return nil
+	}
+
+	return cmd
+}
+
+func restoreCategory(svc CategoryService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore a deleted category under its former parent",
+	}
+
+	var categoryIDStr string
+	cmd.Flags().StringVarP(&categoryIDStr, "id", "i", "", "Category ID")
+	cmd.MarkFlagRequired("id")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		categoryID, err := uuid.Parse(categoryIDStr)
+		if err != nil {
+			return fmt.Errorf("invalid category ID: %w", err)
+		}
+
+		category, err := svc.Restore(cmd.Context(), categoryID)
+		if err != nil {
+			return err
+		}
+
+		cmd.Println("Restored category:")
+		Print(cmd, category)
 		return nil
 	}
 
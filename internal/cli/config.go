@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+	"github.com/sunnyyssh/designing-software-cw1/internal/settings"
+)
+
+func Config(set *settings.Settings) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the config file and its profiles",
+		// Config commands must work before the connection is configured.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	}
+	cmd.AddCommand(
+		showConfig(set),
+		setConfig(set),
+		useProfile(set),
+	)
+	return cmd
+}
+
+func showConfig(set *settings.Settings) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show effective settings and available profiles",
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		file, err := settings.Load(set.Path)
+		if err != nil {
+			return err
+		}
+
+		effective := *set
+		effective.Profile = set.Profile.Redacted()
+		profiles := make(map[string]settings.Profile, len(file.Profiles))
+		for name, p := range file.Profiles {
+			profiles[name] = p.Redacted()
+		}
+
+		cmd.Println("Effective settings:")
+		Print(cmd, effective)
+		cmd.Println("Profiles:")
+		Print(cmd, profiles)
+		return nil
+	}
+
+	return cmd
+}
+
+func setConfig(set *settings.Settings) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: fmt.Sprintf("Set a key of the selected profile, keys: %v", settings.Keys),
+		Args:  cobra.ExactArgs(2),
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		// Values are checked here, an invalid one would be saved and fail only when used.
+		// An empty default account or currency unsets it.
+		key, value := args[0], args[1]
+		switch {
+		case key == "output" && !slices.Contains(OutputFormats, value):
+			return fmt.Errorf("unknown output format %q, expected one of %v", value, OutputFormats)
+		case key == "default_currency" && value != "":
+			code, err := domain.ParseCurrency(value)
+			if err != nil {
+				return fmt.Errorf("%w %q, expected an ISO 4217 code like RUB", err, value)
+			}
+			value = code
+		case key == "default_account" && value != "":
+			if _, err := uuid.Parse(value); err != nil {
+				return fmt.Errorf("invalid default account %q, expected an account ID: %w", value, err)
+			}
+		}
+
+		file, err := settings.Load(set.Path)
+		if err != nil {
+			return err
+		}
+
+		if err := file.Set(set.ProfileName, key, value); err != nil {
+			return err
+		}
+		if err := file.Save(set.Path); err != nil {
+			return err
+		}
+
+		cmd.Printf("Set %s in profile %q\n", key, set.ProfileName)
+		return nil
+	}
+
+	return cmd
+}
+
+func useProfile(set *settings.Settings) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "use-profile <name>",
+		Short: "Make a profile the default one",
+		Args:  cobra.ExactArgs(1),
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		file, err := settings.Load(set.Path)
+		if err != nil {
+			return err
+		}
+
+		if err := file.UseProfile(args[0]); err != nil {
+			return err
+		}
+		if err := file.Save(set.Path); err != nil {
+			return err
+		}
+
+		cmd.Printf("Using profile %q\n", args[0])
+		return nil
+	}
+
+	return cmd
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/services"
+	"github.com/sunnyyssh/designing-software-cw1/internal/settings"
+)
+
+type ForecastService interface {
+	Forecast(ctx context.Context, req services.ForecastRequest) (*dto.ForecastDTO, error)
+}
+
+func Forecast(svc ForecastService, set *settings.Settings) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "forecast",
+		Short: "Project daily balances of an account from schedules and past spending",
+		Long: `Project daily balances of an account for the next days.
+Every day gets the scheduled operations falling on it plus the average daily
+income and spending of each category over the lookback period.`,
+	}
+
+	var (
+		accIDStr string
+		days     int
+		lookback int
+	)
+	cmd.Flags().StringVarP(&accIDStr, "account", "a", set.DefaultAccount, "Account ID")
+	cmd.Flags().IntVarP(&days, "days", "d", 30, "Days to project")
+	cmd.Flags().IntVar(&lookback, "lookback", 90, "Days of history to average")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		accID, err := uuid.Parse(accIDStr)
+		if err != nil {
+			return fmt.Errorf("invalid account ID: %w", err)
+		}
+
+		forecast, err := svc.Forecast(cmd.Context(), services.ForecastRequest{
+			AccountID:    accID,
+			Days:         days,
+			LookbackDays: lookback,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to forecast: %w", err)
+		}
+
+		cmd.Println("Forecast:")
+		Print(cmd, forecast)
+		if forecast.FirstNegative != nil {
+			cmd.Printf("Balance is projected to go below zero on %s\n", forecast.FirstNegative.Format("2006-01-02"))
+		} else {
+			cmd.Printf("Balance is projected to stay non-negative for %d days\n", forecast.Days)
+		}
+		return nil
+	}
+
+	return cmd
+}
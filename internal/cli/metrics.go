@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+)
+
+type MetricsServer interface {
+	Serve(ctx context.Context, addr string) error
+}
+
+func Metrics(srv MetricsServer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "metrics",
+		Short: "Prometheus metrics",
+	}
+	cmd.AddCommand(
+		serveMetrics(srv),
+	)
+	return cmd
+}
+
+func serveMetrics(srv MetricsServer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve /metrics with pool stats, balances and operation counts until interrupted",
+		Long: `Serve /metrics until interrupted. Service call and query metrics are updated by the
+running process only, to get them for long-running commands like "outbox dispatch"
+pass --metrics-addr to those commands instead.`,
+	}
+
+	var addr string
+	cmd.Flags().StringVar(&addr, "addr", ":9090", "Address to listen on")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		cmd.PrintErrf("Serving metrics on %s/metrics, press Ctrl-C to stop\n", addr)
+		return srv.Serve(ctx, addr)
+	}
+
+	return cmd
+}
@@ -3,21 +3,30 @@ package cli
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
 	"github.com/sunnyyssh/designing-software-cw1/internal/application/services"
+	"github.com/sunnyyssh/designing-software-cw1/internal/settings"
 )
 
 type OperationService interface {
 	Get(ctx context.Context, id uuid.UUID) (*dto.OperationDTO, error)
 	List(ctx context.Context) ([]dto.OperationDTO, error)
-	ApplyOperation(ctx context.Context, req services.ApplyOperationRequest) (*dto.BankAccountDTO, error)
+	Find(ctx context.Context, req services.FindOperationsRequest) ([]dto.OperationDTO, error)
+	AddTags(ctx context.Context, id uuid.UUID, tags []string) (*dto.OperationDTO, error)
+	RemoveTags(ctx context.Context, id uuid.UUID, tags []string) (*dto.OperationDTO, error)
+	Split(ctx context.Context, id uuid.UUID, parts []services.SplitPart) (*dto.OperationDTO, error)
+	ApplyOperation(ctx context.Context, req services.ApplyOperationRequest) (*services.ApplyOperationResponse, error)
 	Transfer(ctx context.Context, req services.TransferRequest) (*services.TransferResponse, error)
+	Duplicates(ctx context.Context, req services.DuplicatesRequest) ([]dto.DuplicatePairDTO, error)
 }
 
-func Operation(svc OperationService) *cobra.Command {
+func Operation(svc OperationService, set *settings.Settings) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "operation",
 		Short: "Operation-connected actions",
@@ -25,9 +34,12 @@ func Operation(svc OperationService) *cobra.Command {
 	cmd.AddCommand(
 		getOperation(svc),
 		listOperations(svc),
-		applyIncome(svc),
-		applyOutcome(svc),
-		transfer(svc),
+		applyIncome(svc, set.DefaultAccount),
+		applyOutcome(svc, set.DefaultAccount),
+		transfer(svc, set.DefaultAccount),
+		operationTag(svc),
+		splitOperation(svc),
+		listDuplicates(svc),
 	)
 	return cmd
 }
@@ -54,7 +66,7 @@ func getOperation(svc OperationService) *cobra.Command {
 		}
 
 		cmd.Println("Operation details:")
-		PrettyJSON(cmd, operation)
+		Print(cmd, operation)
 		return nil
 	}
 
@@ -67,104 +79,220 @@ func listOperations(svc OperationService) *cobra.Command {
 		Short: "List all operations",
 	}
 
+	var tags []string
+	cmd.Flags().StringSliceVarP(&tags, "tag", "g", nil, "Only operations having all of these tags")
+
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		operations, err := svc.List(cmd.Context())
+		operations, err := svc.Find(cmd.Context(), services.FindOperationsRequest{Tags: tags})
 		if err != nil {
 			return fmt.Errorf("failed to list operations: %w", err)
 		}
 
 		cmd.Println("Operations:")
-		PrettyJSON(cmd, operations)
+		Print(cmd, operations)
 		return nil
 	}
 
 	return cmd
 }
 
-func applyIncome(svc OperationService) *cobra.Command {
+func applyIncome(svc OperationService, defaultAccount string) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "income",
 		Short: "Apply income operation on account",
 	}
 
 	var (
-		accIDstr string
-		amount   int64
+		accIDstr       string
+		amount         int64
+		description    string
+		categoryIDStr  string
+		tags           []string
+		idempotencyKey string
+		allowDuplicate bool
+		atStr          string
 	)
-	cmd.PersistentFlags().StringVarP(&accIDstr, "acc-id", "i", "", "Account ID")
+	cmd.PersistentFlags().StringVarP(&accIDstr, "acc-id", "i", defaultAccount, "Account ID")
 	cmd.PersistentFlags().Int64VarP(&amount, "amount", "m", 0, "Amount of money")
+	cmd.PersistentFlags().StringVarP(&description, "description", "d", "", "Operation description")
+	cmd.PersistentFlags().StringVarP(&categoryIDStr, "category", "c", "", "Category ID")
+	cmd.PersistentFlags().StringSliceVarP(&tags, "tag", "g", nil, "Tags, repeat the flag or separate by commas")
+	cmd.PersistentFlags().StringVar(&idempotencyKey, "idempotency-key", "", "Retrying with the same key returns the first result instead of applying again")
+	cmd.PersistentFlags().StringVar(&atStr, "at", "", "When the operation was made, YYYY-MM-DD[ HH:MM] or RFC 3339 (default now)")
+	cmd.PersistentFlags().BoolVar(&allowDuplicate, "allow-duplicate", false, "Apply even if a recent operation looks the same, warning about it")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		accID, err := uuid.Parse(accIDstr)
 		if err != nil {
 			return err
 		}
+		categoryID, err := parseOptionalID(categoryIDStr)
+		if err != nil {
+			return fmt.Errorf("invalid category ID: %w", err)
+		}
+		at, err := parseOptionalTime(atStr)
+		if err != nil {
+			return err
+		}
 
-		acc, err := svc.ApplyOperation(cmd.Context(), services.ApplyOperationRequest{
-			AccountID:     accID,
-			Amount:        amount,
-			OperationType: "income",
+		resp, err := svc.ApplyOperation(cmd.Context(), services.ApplyOperationRequest{
+			AccountID:      accID,
+			Amount:         amount,
+			OperationType:  "income",
+			Description:    description,
+			CategoryID:     categoryID,
+			Tags:           tags,
+			At:             at,
+			IdempotencyKey: idempotencyKey,
+			AllowDuplicate: allowDuplicate,
 		})
 		if err != nil {
 			return err
 		}
+		warnDuplicates(cmd, resp)
 
 		cmd.Println(`Income operation applied on account`)
-		PrettyJSON(cmd, acc)
+		Print(cmd, resp.Account)
 		return nil
 	}
 	return cmd
 }
 
-func applyOutcome(svc OperationService) *cobra.Command {
+func applyOutcome(svc OperationService, defaultAccount string) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "outcome",
 		Short: "Apply outcome operation on account",
 	}
 
 	var (
-		accIDstr string
-		amount   int64
+		accIDstr       string
+		amount         int64
+		description    string
+		categoryIDStr  string
+		tags           []string
+		strict         bool
+		idempotencyKey string
+		allowDuplicate bool
+		atStr          string
 	)
-	cmd.PersistentFlags().StringVarP(&accIDstr, "acc-id", "i", "", "Account ID")
+	cmd.PersistentFlags().StringVarP(&accIDstr, "acc-id", "i", defaultAccount, "Account ID")
 	cmd.PersistentFlags().Int64VarP(&amount, "amount", "m", 0, "Amount of money")
+	cmd.PersistentFlags().StringVarP(&description, "description", "d", "", "Operation description")
+	cmd.PersistentFlags().StringVarP(&categoryIDStr, "category", "c", "", "Category ID")
+	cmd.PersistentFlags().StringSliceVarP(&tags, "tag", "g", nil, "Tags, repeat the flag or separate by commas")
+	cmd.PersistentFlags().StringVar(&idempotencyKey, "idempotency-key", "", "Retrying with the same key returns the first result instead of applying again")
+	cmd.PersistentFlags().StringVar(&atStr, "at", "", "When the operation was made, YYYY-MM-DD[ HH:MM] or RFC 3339 (default now)")
+	cmd.PersistentFlags().BoolVar(&allowDuplicate, "allow-duplicate", false, "Apply even if a recent operation looks the same, warning about it")
+	cmd.PersistentFlags().BoolVar(&strict, "strict", false, "Fail instead of warning when a budget would be exceeded")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		accID, err := uuid.Parse(accIDstr)
 		if err != nil {
 			return err
 		}
+		categoryID, err := parseOptionalID(categoryIDStr)
+		if err != nil {
+			return fmt.Errorf("invalid category ID: %w", err)
+		}
+		at, err := parseOptionalTime(atStr)
+		if err != nil {
+			return err
+		}
 
-		acc, err := svc.ApplyOperation(cmd.Context(), services.ApplyOperationRequest{
-			AccountID:     accID,
-			Amount:        amount,
-			OperationType: "outcome",
+		resp, err := svc.ApplyOperation(cmd.Context(), services.ApplyOperationRequest{
+			AccountID:      accID,
+			Amount:         amount,
+			OperationType:  "outcome",
+			Description:    description,
+			CategoryID:     categoryID,
+			Tags:           tags,
+			At:             at,
+			IdempotencyKey: idempotencyKey,
+			AllowDuplicate: allowDuplicate,
+			StrictBudget:   strict,
 		})
 		if err != nil {
 			return err
 		}
+		warnDuplicates(cmd, resp)
+		for _, b := range resp.BudgetExceeded {
+			cmd.PrintErrf("Warning: %s budget is exceeded: limit %d, spent %d\n", b.Period, b.Limit, b.Spent)
+		}
 
 		cmd.Println(`Outcome operation applied on account`)
-		PrettyJSON(cmd, acc)
+		Print(cmd, resp.Account)
 		return nil
 	}
 	return cmd
 }
 
-func transfer(svc OperationService) *cobra.Command {
+func warnDuplicates(cmd *cobra.Command, resp *services.ApplyOperationResponse) {
+	for _, d := range resp.DuplicateOf {
+		cmd.PrintErrf("Warning: probable duplicate of operation %s made at %s\n", d.ID, d.Time.Format(time.DateTime))
+	}
+}
+
+func listDuplicates(svc OperationService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "duplicates",
+		Short: "List pairs of operations that look like one entered twice",
+		Long: `List pairs of operations of the same account, type and amount made within 5 minutes
+of each other with a similar description. The later operation of a pair is the suspected
+duplicate.`,
+	}
+
+	var (
+		accIDStr string
+		sinceStr string
+	)
+	cmd.Flags().StringVarP(&accIDStr, "acc-id", "i", "", "Account ID (default all accounts)")
+	cmd.Flags().StringVar(&sinceStr, "since", "", "Only operations made at or after this time")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		accID, err := parseOptionalID(accIDStr)
+		if err != nil {
+			return fmt.Errorf("invalid account ID: %w", err)
+		}
+		req := services.DuplicatesRequest{AccountID: accID}
+		if sinceStr != "" {
+			since, err := parseTime(sinceStr)
+			if err != nil {
+				return err
+			}
+			req.Since = &since
+		}
+
+		pairs, err := svc.Duplicates(cmd.Context(), req)
+		if err != nil {
+			return fmt.Errorf("failed to find duplicates: %w", err)
+		}
+
+		cmd.Println("Suspected duplicates:")
+		Print(cmd, pairs)
+		return nil
+	}
+
+	return cmd
+}
+
+func transfer(svc OperationService, defaultAccount string) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "transfer",
 		Short: "Transfer money from one account to the another one",
 	}
 
 	var (
-		fromAccIDstr string
-		toAccIDstr   string
-		amount       int64
+		fromAccIDstr   string
+		toAccIDstr     string
+		amount         int64
+		idempotencyKey string
+		atStr          string
 	)
-	cmd.PersistentFlags().StringVarP(&fromAccIDstr, "from-acc-id", "f", "", "From account ID")
+	cmd.PersistentFlags().StringVarP(&fromAccIDstr, "from-acc-id", "f", defaultAccount, "From account ID")
 	cmd.PersistentFlags().StringVarP(&toAccIDstr, "to-acc-id", "t", "", "To account ID")
 	cmd.PersistentFlags().Int64VarP(&amount, "amount", "m", 0, "Amount of money")
+	cmd.PersistentFlags().StringVar(&idempotencyKey, "idempotency-key", "", "Retrying with the same key returns the first result instead of applying again")
+	cmd.PersistentFlags().StringVar(&atStr, "at", "", "When the operation was made, YYYY-MM-DD[ HH:MM] or RFC 3339 (default now)")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		fromAccID, err := uuid.Parse(fromAccIDstr)
@@ -175,21 +303,139 @@ func transfer(svc OperationService) *cobra.Command {
 		if err != nil {
 			return err
 		}
+		at, err := parseOptionalTime(atStr)
+		if err != nil {
+			return err
+		}
 
 		resp, err := svc.Transfer(cmd.Context(), services.TransferRequest{
-			FromAccountID: fromAccID,
-			ToAccountID:   toAccID,
-			Amount:        amount,
+			FromAccountID:  fromAccID,
+			ToAccountID:    toAccID,
+			Amount:         amount,
+			At:             at,
+			IdempotencyKey: idempotencyKey,
 		})
 		if err != nil {
 			return err
 		}
 
 		cmd.Println(`Outcome operation applied on account`)
-		PrettyJSON(cmd, resp.FromAccount)
+		Print(cmd, resp.FromAccount)
 		cmd.Println(`Income operation applied on account`)
-		PrettyJSON(cmd, resp.ToAccount)
+		Print(cmd, resp.ToAccount)
 		return nil
 	}
 	return cmd
 }
+
+func operationTag(svc OperationService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tag",
+		Short: "Add or remove tags of an operation",
+	}
+	cmd.AddCommand(
+		changeTags("add", "Add tags to an operation", svc.AddTags),
+		changeTags("remove", "Remove tags from an operation", svc.RemoveTags),
+	)
+	return cmd
+}
+
+func changeTags(
+	use, short string,
+	change func(ctx context.Context, id uuid.UUID, tags []string) (*dto.OperationDTO, error),
+) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+	}
+
+	var (
+		operationIDStr string
+		tags           []string
+	)
+	cmd.Flags().StringVarP(&operationIDStr, "id", "i", "", "Operation ID")
+	cmd.Flags().StringSliceVarP(&tags, "tag", "g", nil, "Tags, repeat the flag or separate by commas")
+	cmd.MarkFlagRequired("id")
+	cmd.MarkFlagRequired("tag")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		operationID, err := uuid.Parse(operationIDStr)
+		if err != nil {
+			return fmt.Errorf("invalid operation ID: %w", err)
+		}
+
+		operation, err := change(cmd.Context(), operationID, tags)
+		if err != nil {
+			return err
+		}
+
+		cmd.Println("Operation details:")
+		Print(cmd, operation)
+		return nil
+	}
+
+	return cmd
+}
+
+func splitOperation(svc OperationService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "split",
+		Short: "Divide an operation between several categories",
+		Example: `  bankcli operation split -i <op-id> -p <groceries-id>=3200 -p <household-id>=1800
+  bankcli operation split -i <op-id> --clear`,
+	}
+
+	var (
+		operationIDStr string
+		partStrs       []string
+		clearSplits    bool
+	)
+	cmd.Flags().StringVarP(&operationIDStr, "id", "i", "", "Operation ID")
+	cmd.Flags().StringArrayVarP(&partStrs, "part", "p", nil, "Split part as <category-id>=<amount>, parts must sum to the operation amount")
+	cmd.Flags().BoolVar(&clearSplits, "clear", false, "Remove splits of the operation")
+	cmd.MarkFlagRequired("id")
+	cmd.MarkFlagsOneRequired("part", "clear")
+	cmd.MarkFlagsMutuallyExclusive("part", "clear")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		operationID, err := uuid.Parse(operationIDStr)
+		if err != nil {
+			return fmt.Errorf("invalid operation ID: %w", err)
+		}
+		parts := make([]services.SplitPart, 0, len(partStrs))
+		for _, s := range partStrs {
+			part, err := parseSplitPart(s)
+			if err != nil {
+				return err
+			}
+			parts = append(parts, part)
+		}
+
+		operation, err := svc.Split(cmd.Context(), operationID, parts)
+		if err != nil {
+			return err
+		}
+
+		cmd.Println("Operation details:")
+		Print(cmd, operation)
+		return nil
+	}
+
+	return cmd
+}
+
+func parseSplitPart(s string) (services.SplitPart, error) {
+	idStr, amountStr, ok := strings.Cut(s, "=")
+	if !ok {
+		return services.SplitPart{}, fmt.Errorf("invalid split part %q, expected <category-id>=<amount>", s)
+	}
+	categoryID, err := uuid.Parse(idStr)
+	if err != nil {
+		return services.SplitPart{}, fmt.Errorf("invalid category ID in split part %q: %w", s, err)
+	}
+	amount, err := strconv.ParseInt(amountStr, 10, 64)
+	if err != nil {
+		return services.SplitPart{}, fmt.Errorf("invalid amount in split part %q", s)
+	}
+	return services.SplitPart{CategoryID: categoryID, Amount: amount}, nil
+}
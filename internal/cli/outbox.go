@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/services"
+	"github.com/sunnyyssh/designing-software-cw1/internal/settings"
+)
+
+type OutboxService interface {
+	Dispatch(ctx context.Context, req services.DispatchRequest) (*services.DispatchResponse, error)
+	List(ctx context.Context, status string) ([]dto.OutboxMessageDTO, error)
+	Requeue(ctx context.Context, id uuid.UUID) (*dto.OutboxMessageDTO, error)
+}
+
+func Outbox(svc OutboxService, set *settings.Settings) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "outbox",
+		Short: "Deliver change events to webhooks",
+	}
+	cmd.AddCommand(
+		dispatchOutbox(svc, set),
+		listOutbox(svc),
+		requeueOutbox(svc),
+	)
+	return cmd
+}
+
+func dispatchOutbox(svc OutboxService, set *settings.Settings) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dispatch",
+		Short: "Deliver due messages to webhooks, once or continuously with --interval",
+		Long: `Deliver due messages to the webhook_urls of the profile.
+Every request carries X-Bankcli-Signature: sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">
+keyed by webhook_secret, the timestamp is in X-Bankcli-Timestamp.
+Failed messages are retried with exponential backoff and become dead after --max-attempts.
+Messages are claimed for --lease and sent after the claim is committed, a dispatcher stopped
+while sending leaves them to be sent again once the lease is over.`,
+	}
+
+	var (
+		batchSize   int
+		maxAttempts int
+		backoff     time.Duration
+		interval    time.Duration
+		lease       time.Duration
+	)
+	cmd.Flags().IntVar(&batchSize, "batch", 100, "Messages claimed per transaction")
+	cmd.Flags().IntVar(&maxAttempts, "max-attempts", 10, "Attempts before a message becomes dead")
+	cmd.Flags().DurationVar(&backoff, "backoff", 30*time.Second, "Delay before the first retry, doubled for every next one")
+	cmd.Flags().DurationVar(&lease, "lease", 10*time.Minute, "How long claimed messages are hidden from other dispatchers while being sent")
+	cmd.Flags().DurationVar(&interval, "interval", 0, "Keep dispatching with this pause between rounds (default run once)")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if len(set.WebhookURLList()) == 0 {
+			return errors.New("no webhook URLs: run `bankcli config set webhook_urls <url>[,<url>...]`")
+		}
+		if set.WebhookSecret == "" {
+			return errors.New("no webhook secret: run `bankcli config set webhook_secret <secret>`")
+		}
+		if batchSize <= 0 || maxAttempts <= 0 || lease <= 0 {
+			return errors.New("--batch, --max-attempts and --lease must be positive")
+		}
+		req := services.DispatchRequest{
+			BatchSize:   batchSize,
+			MaxAttempts: maxAttempts,
+			Backoff:     backoff,
+			Lease:       lease,
+		}
+
+		for {
+			resp, err := svc.Dispatch(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+			cmd.Println("Dispatch result:")
+			Print(cmd, resp)
+
+			if interval <= 0 {
+				return nil
+			}
+			select {
+			case <-cmd.Context().Done():
+				return nil
+			case <-time.After(interval):
+			}
+		}
+	}
+
+	return cmd
+}
+
+func listOutbox(svc OutboxService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List outbox messages by status",
+	}
+
+	var status string
+	cmd.Flags().StringVarP(&status, "status", "s", "dead", "Message status (pending/delivered/dead)")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		msgs, err := svc.List(cmd.Context(), status)
+		if err != nil {
+			return fmt.Errorf("failed to list outbox: %w", err)
+		}
+
+		cmd.Println("Outbox messages:")
+		Print(cmd, msgs)
+		return nil
+	}
+
+	return cmd
+}
+
+func requeueOutbox(svc OutboxService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "requeue",
+		Short: "Retry a dead message",
+	}
+
+	var idStr string
+	cmd.Flags().StringVarP(&idStr, "id", "i", "", "Message ID")
+	cmd.MarkFlagRequired("id")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return fmt.Errorf("invalid message ID: %w", err)
+		}
+
+		msg, err := svc.Requeue(cmd.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		cmd.Println("Requeued message:")
+		Print(cmd, msg)
+		return nil
+	}
+
+	return cmd
+}
@@ -1,11 +1,37 @@
 package cli
 
 import (
+	"bytes"
 	"encoding/json"
 
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
+const (
+	OutputJSON = "json"
+	OutputYAML = "yaml"
+)
+
+var OutputFormats = []string{OutputJSON, OutputYAML}
+
+// Print writes t in the format selected by the --output flag.
+func Print(cmd *cobra.Command, t any) {
+	switch OutputFormat(cmd) {
+	case OutputYAML:
+		PrettyYAML(cmd, t)
+	default:
+		PrettyJSON(cmd, t)
+	}
+}
+
+func OutputFormat(cmd *cobra.Command) string {
+	if f := cmd.Flag("output"); f != nil {
+		return f.Value.String()
+	}
+	return OutputJSON
+}
+
 func PrettyJSON(cmd *cobra.Command, t any) {
 	encoder := json.NewEncoder(cmd.OutOrStdout())
 	encoder.SetIndent("\t", "\t")
@@ -14,3 +40,46 @@ func PrettyJSON(cmd *cobra.Command, t any) {
 	}
 	cmd.Println()
 }
+
+// PrettyYAML goes through JSON first, so that DTO json tags are respected.
+func PrettyYAML(cmd *cobra.Command, t any) {
+	raw, err := json.Marshal(t)
+	if err != nil {
+		panic(err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var generic any
+	if err := decoder.Decode(&generic); err != nil {
+		panic(err)
+	}
+
+	encoder := yaml.NewEncoder(cmd.OutOrStdout())
+	encoder.SetIndent(2)
+	if err := encoder.Encode(plainNumbers(generic)); err != nil {
+		panic(err)
+	}
+	cmd.Println()
+}
+
+func plainNumbers(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			v[k] = plainNumbers(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = plainNumbers(item)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	}
+	return v
+}
@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/services"
+	"github.com/sunnyyssh/designing-software-cw1/internal/settings"
+)
+
+type ScheduleService interface {
+	Add(ctx context.Context, req services.AddScheduleRequest) (*dto.ScheduledOperationDTO, error)
+	List(ctx context.Context) ([]dto.ScheduledOperationDTO, error)
+	Remove(ctx context.Context, id uuid.UUID) (*dto.ScheduledOperationDTO, error)
+	Run(ctx context.Context, until time.Time) (*services.RunScheduleResponse, error)
+}
+
+func Schedule(svc ScheduleService, set *settings.Settings) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Recurring and scheduled operations",
+	}
+	cmd.AddCommand(
+		addSchedule(svc, set.DefaultAccount),
+		listSchedules(svc),
+		removeSchedule(svc),
+		runSchedules(svc),
+	)
+	return cmd
+}
+
+func addSchedule(svc ScheduleService, defaultAccount string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add",
+		Short: "Schedule a recurring operation",
+		Example: `  bankcli schedule add -i <acc-id> -t income -m 100000 -r "FREQ=MONTHLY;BYMONTHDAY=5" -d salary
+  bankcli schedule add -i <acc-id> -t outcome -m 499 -r "FREQ=WEEKLY;BYDAY=MO" --start 2026-01-05`,
+	}
+
+	var (
+		accIDstr      string
+		typ           string
+		amount        int64
+		recurrence    string
+		startStr      string
+		description   string
+		categoryIDStr string
+	)
+	cmd.Flags().StringVarP(&accIDstr, "acc-id", "i", defaultAccount, "Account ID")
+	cmd.Flags().StringVarP(&typ, "type", "t", "", "Operation type (income/outcome)")
+	cmd.Flags().Int64VarP(&amount, "amount", "m", 0, "Amount of money")
+	cmd.Flags().StringVarP(&recurrence, "rrule", "r", "", "RFC 5545 recurrence rule, e.g. FREQ=MONTHLY;BYMONTHDAY=1")
+	cmd.Flags().StringVar(&startStr, "start", "", "First possible occurrence (default now)")
+	cmd.Flags().StringVarP(&description, "description", "d", "", "Operation description")
+	cmd.Flags().StringVarP(&categoryIDStr, "category", "c", "", "Category ID")
+	cmd.MarkFlagRequired("type")
+	cmd.MarkFlagRequired("amount")
+	cmd.MarkFlagRequired("rrule")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		accID, err := uuid.Parse(accIDstr)
+		if err != nil {
+			return fmt.Errorf("invalid account ID: %w", err)
+		}
+		categoryID, err := parseOptionalID(categoryIDStr)
+		if err != nil {
+			return fmt.Errorf("invalid category ID: %w", err)
+		}
+		start := time.Now()
+		if startStr != "" {
+			if start, err = parseTime(startStr); err != nil {
+				return err
+			}
+		}
+
+		schedule, err := svc.Add(cmd.Context(), services.AddScheduleRequest{
+			AccountID:     accID,
+			Amount:        amount,
+			OperationType: typ,
+			Description:   description,
+			CategoryID:    categoryID,
+			Recurrence:    recurrence,
+			StartAt:       start,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add schedule: %w", err)
+		}
+
+		cmd.Println("Scheduled operation:")
+		Print(cmd, schedule)
+		return nil
+	}
+
+	return cmd
+}
+
+func listSchedules(svc ScheduleService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List scheduled operations",
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		schedules, err := svc.List(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to list schedules: %w", err)
+		}
+
+		cmd.Println("Scheduled operations:")
+		Print(cmd, schedules)
+		return nil
+	}
+
+	return cmd
+}
+
+func removeSchedule(svc ScheduleService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remove",
+		Short: "Remove a scheduled operation",
+	}
+
+	var idStr string
+	cmd.Flags().StringVarP(&idStr, "id", "i", "", "Schedule ID")
+	cmd.MarkFlagRequired("id")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			return fmt.Errorf("invalid schedule ID: %w", err)
+		}
+
+		schedule, err := svc.Remove(cmd.Context(), id)
+		if err != nil {
+			return fmt.Errorf("failed to remove schedule: %w", err)
+		}
+
+		cmd.Println("Removed scheduled operation:")
+		Print(cmd, schedule)
+		return nil
+	}
+
+	return cmd
+}
+
+func runSchedules(svc ScheduleService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Apply all due occurrences, safe to call repeatedly (e.g. from cron)",
+	}
+
+	var untilStr string
+	cmd.Flags().StringVar(&untilStr, "until", "", "Apply occurrences up to this time (default now)")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		until := time.Now()
+		if untilStr != "" {
+			var err error
+			if until, err = parseTime(untilStr); err != nil {
+				return err
+			}
+		}
+
+		resp, err := svc.Run(cmd.Context(), until)
+		if err != nil {
+			return fmt.Errorf("failed to run schedules: %w", err)
+		}
+
+		cmd.Println("Schedule run result:")
+		Print(cmd, resp)
+		if len(resp.Failed) > 0 {
+			return fmt.Errorf("%d occurrence(s) failed", len(resp.Failed))
+		}
+		return nil
+	}
+
+	return cmd
+}
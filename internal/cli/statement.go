@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"embed"
+	"encoding/csv"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+)
+
+const (
+	StatementText     = "text"
+	StatementHTML     = "html"
+	StatementMarkdown = "markdown"
+	StatementCSV      = "csv"
+)
+
+var StatementFormats = []string{StatementText, StatementHTML, StatementMarkdown, StatementCSV}
+
+//go:embed templates
+var templates embed.FS
+
+const statementTimeLayout = "2006-01-02 15:04"
+
+func formatStatementTime(t time.Time) string {
+	return t.Local().Format(statementTimeLayout)
+}
+
+var (
+	textStatement = template.Must(template.New("statement.txt.tmpl").
+			Funcs(template.FuncMap{"date": formatStatementTime}).
+			ParseFS(templates, "templates/statement.txt.tmpl"))
+	markdownStatement = template.Must(template.New("statement.md.tmpl").
+				Funcs(template.FuncMap{"date": formatStatementTime, "md": escapeMarkdown}).
+				ParseFS(templates, "templates/statement.md.tmpl"))
+	htmlStatement = htmltemplate.Must(htmltemplate.New("statement.html.tmpl").
+			Funcs(htmltemplate.FuncMap{"date": formatStatementTime}).
+			ParseFS(templates, "templates/statement.html.tmpl"))
+)
+
+// escapeMarkdown keeps user text from breaking table cells and formatting.
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`", "<", "&lt;", "\n", " ",
+	).Replace(s)
+}
+
+// renderStatement writes the statement in one of StatementFormats.
+func renderStatement(w io.Writer, format string, statement *dto.StatementDTO) error {
+	switch format {
+	case StatementText:
+		return textStatement.Execute(w, statement)
+	case StatementMarkdown:
+		return markdownStatement.Execute(w, statement)
+	case StatementHTML:
+		return htmlStatement.Execute(w, statement)
+	case StatementCSV:
+		return writeStatementCSV(w, statement)
+	default:
+		return fmt.Errorf("unknown statement format %q, expected one of %v", format, StatementFormats)
+	}
+}
+
+// writeStatementCSV writes one row per operation between "opening" and "closing" rows,
+// which carry the balances at the start and the end of the period.
+func writeStatementCSV(w io.Writer, statement *dto.StatementDTO) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"operation_id", "time", "type", "amount", "balance", "category", "description"})
+	cw.Write([]string{"", statement.From.Format(time.RFC3339), "opening", "", strconv.FormatInt(statement.OpeningBalance, 10), "", ""})
+	for _, l := range statement.Lines {
+		cw.Write([]string{
+			l.OperationID.String(),
+			l.Time.Format(time.RFC3339),
+			l.Type,
+			strconv.FormatInt(l.Amount, 10),
+			strconv.FormatInt(l.Balance, 10),
+			l.Category,
+			l.Description,
+		})
+	}
+	cw.Write([]string{"", statement.To.Format(time.RFC3339), "closing", "", strconv.FormatInt(statement.ClosingBalance, 10), "", ""})
+	cw.Flush()
+	return cw.Error()
+}
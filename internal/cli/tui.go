@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/sunnyyssh/designing-software-cw1/internal/tui"
+)
+
+func TUI(accSvc BankAccountService, opSvc OperationService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tui",
+		Short: "Full-screen terminal UI for browsing accounts and operations",
+	}
+
+	var refresh time.Duration
+	cmd.Flags().DurationVarP(&refresh, "refresh", "r", 5*time.Second, "Live refresh interval (0 disables it)")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return tui.New(accSvc, opSvc, refresh).Run(cmd.Context())
+	}
+
+	return cmd
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/services"
+)
+
+type UserService interface {
+	Create(ctx context.Context, name string) (*services.UserTokenResponse, error)
+	List(ctx context.Context) ([]dto.UserDTO, error)
+	Me(ctx context.Context) (*dto.UserDTO, error)
+	RotateToken(ctx context.Context) (*services.UserTokenResponse, error)
+}
+
+func User(svc UserService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "user",
+		Short: "Users and their API tokens",
+	}
+	cmd.AddCommand(
+		createUser(svc),
+		listUsers(svc),
+		whoami(svc),
+		rotateToken(svc),
+	)
+	return cmd
+}
+
+func createUser(svc UserService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a user and print its API token",
+	}
+
+	var name string
+	cmd.Flags().StringVarP(&name, "name", "n", "", "Unique user name")
+	cmd.MarkFlagRequired("name")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		resp, err := svc.Create(cmd.Context(), name)
+		if err != nil {
+			return err
+		}
+
+		cmd.Println("Created a user:")
+		Print(cmd, resp.User)
+		if len(resp.Adopted) > 0 {
+			cmd.Println("The first user owns accounts created before users existed:")
+			Print(cmd, resp.Adopted)
+		}
+		printToken(cmd, resp.Token)
+		return nil
+	}
+	return cmd
+}
+
+func listUsers(svc UserService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List all users",
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		users, err := svc.List(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		cmd.Println("Users:")
+		Print(cmd, users)
+		return nil
+	}
+	return cmd
+}
+
+func whoami(svc UserService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "whoami",
+		Short: "Show the user the API token belongs to",
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		user, err := svc.Me(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		Print(cmd, user)
+		return nil
+	}
+	return cmd
+}
+
+func rotateToken(svc UserService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rotate-token",
+		Short: "Replace the API token of the current user, the old one stops working",
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		resp, err := svc.RotateToken(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		printToken(cmd, resp.Token)
+		return nil
+	}
+	return cmd
+}
+
+func printToken(cmd *cobra.Command, token string) {
+	cmd.Println("API token, it is shown only once:")
+	cmd.Println(token)
+	cmd.Println("Use it with `bankcli config set api_token <token>` or BANKCLI_API_TOKEN.")
+}
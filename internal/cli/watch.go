@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/services"
+)
+
+type WatchService interface {
+	Watch(ctx context.Context, req services.WatchRequest, fn func(*dto.ChangeDTO) error) error
+}
+
+func Watch(svc WatchService) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Stream new operations and account changes as they are committed, until interrupted",
+	}
+
+	var accountIDStr string
+	cmd.Flags().StringVarP(&accountIDStr, "account", "a", "", "Only changes of this account")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		accountID, err := parseOptionalID(accountIDStr)
+		if err != nil {
+			return fmt.Errorf("invalid account ID: %w", err)
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		cmd.PrintErrln("Watching changes, press Ctrl-C to stop")
+		return svc.Watch(ctx, services.WatchRequest{AccountID: accountID}, func(change *dto.ChangeDTO) error {
+			Print(cmd, change)
+			return nil
+		})
+	}
+
+	return cmd
+}
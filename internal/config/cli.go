@@ -1,20 +1,64 @@
 package config
 
 import (
+	"fmt"
+	"slices"
+
 	"github.com/spf13/cobra"
 	"github.com/sunnyyssh/designing-software-cw1/internal/cli"
+	"github.com/sunnyyssh/designing-software-cw1/internal/settings"
 )
 
-func CLI(svc *Services) *cobra.Command {
+func CLI(svc *Services, m *Metrics, tr *Tracing, set *settings.Settings) *cobra.Command {
+	var metricsAddr string
 	cmd := &cobra.Command{
 		Use:   "bankcli",
 		Short: "Bank accounting system CLI",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if output := cli.OutputFormat(cmd); !slices.Contains(cli.OutputFormats, output) {
+				return fmt.Errorf("unknown output format %q, expected one of %v", output, cli.OutputFormats)
+			}
+			if err := set.Validate(); err != nil {
+				return err
+			}
+			ctx, err := svc.UserService.Authenticate(cmd.Context(), set.APIToken)
+			if err != nil {
+				return err
+			}
+			cmd.SetContext(ctx)
+			if metricsAddr != "" {
+				go func() {
+					if err := m.Serve(ctx, metricsAddr); err != nil {
+						cmd.PrintErrf("Warning: metrics server stopped: %v\n", err)
+					}
+				}()
+			}
+			return nil
+		},
 	}
+	// --profile and --tenant are resolved in LoadSettings, they're declared here only to be accepted and documented.
+	cmd.PersistentFlags().String("profile", set.ProfileName, "Config profile to use")
+	cmd.PersistentFlags().String("tenant", set.Tenant, "Household whose data is used, others are isolated")
+	cmd.PersistentFlags().String("output", set.Output, fmt.Sprintf("Output format %v", cli.OutputFormats))
+	cmd.PersistentFlags().StringVar(&metricsAddr, "metrics-addr", "", "Serve /metrics on this address while the command runs")
+
 	cmd.AddCommand(
-		cli.Account(svc.BankAccountService),
-		cli.Operation(svc.OperationService),
+		cli.Account(svc.BankAccountService, svc.StatementService, set),
+		cli.User(svc.UserService),
+		cli.Operation(svc.OperationService, set),
 		cli.Category(svc.CategoryService),
+		cli.Budget(svc.BudgetService),
+		cli.Schedule(svc.ScheduleService, set),
+		cli.Analytics(svc.AnalyticsService),
+		cli.Audit(svc.AuditService),
+		cli.Outbox(svc.OutboxService, set),
+		cli.Watch(svc.WatchService),
+		cli.Forecast(svc.ForecastService, set),
+		cli.TUI(svc.BankAccountService, svc.OperationService),
+		cli.Metrics(m),
+		cli.Config(set),
 	)
+	traceCommands(cmd, tr.Provider.Tracer("github.com/sunnyyssh/designing-software-cw1/internal/cli"))
 
 	return cmd
 }
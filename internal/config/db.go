@@ -2,20 +2,52 @@ package config
 
 import (
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/audit"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/outbox"
 	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
 	"github.com/sunnyyssh/designing-software-cw1/internal/infrastructure/pgrepo"
 )
 
 type DB struct {
+	Transactor      storage.Transactor
 	BankAccountRepo storage.BankAccountRepo
 	CategoryRepo    storage.CategoryRepo
 	OperationRepo   storage.OperationRepo
+	BudgetRepo      storage.BudgetRepo
+	ScheduleRepo    storage.ScheduleRepo
+	AuditRepo       storage.AuditRepo
+	// AccountEventRepo is not audited: it is append-only and mirrors account changes that are.
+	AccountEventRepo storage.AccountEventRepo
+	OutboxRepo       storage.OutboxRepo
+	IdempotencyRepo  storage.IdempotencyRepo
+	UserRepo         storage.UserRepo
+	MembershipRepo   storage.MembershipRepo
+	StatsRepo        storage.StatsRepo
+	ChangeListener   storage.ChangeListener
 }
 
-func NewDB(db *pgxpool.Pool) *DB {
+// NewDB builds repositories of the tenant that record every change to the audit log on behalf
+// of the current user, or actor for unauthenticated calls.
+func NewDB(pool *pgxpool.Pool, tenant, actor string, observer pgrepo.QueryObserver) *DB {
+	db := pgrepo.NewDB(pool, tenant, observer)
+	tx := audit.NewTransactor(pgrepo.NewTransactor(db))
+	auditRepo := pgrepo.NewAuditRepo(db)
+	rec := audit.NewRecorder(tx, auditRepo, actor)
+	outboxRepo := pgrepo.NewOutboxRepo(db)
 	return &DB{
-		BankAccountRepo: pgrepo.NewBankAccountRepo(db),
-		CategoryRepo:    pgrepo.NewCategoryRepo(db),
-		OperationRepo:   pgrepo.NewOperationRepo(db),
+		Transactor:       tx,
+		BankAccountRepo:  audit.NewBankAccountRepo(pgrepo.NewBankAccountRepo(db), rec),
+		CategoryRepo:     audit.NewCategoryRepo(pgrepo.NewCategoryRepo(db), rec),
+		OperationRepo:    audit.NewOperationRepo(pgrepo.NewOperationRepo(db), rec),
+		BudgetRepo:       audit.NewBudgetRepo(pgrepo.NewBudgetRepo(db), rec),
+		ScheduleRepo:     audit.NewScheduleRepo(pgrepo.NewScheduleRepo(db), rec),
+		AuditRepo:        auditRepo,
+		AccountEventRepo: outbox.NewAccountEventRepo(pgrepo.NewAccountEventRepo(db), outboxRepo),
+		OutboxRepo:       outboxRepo,
+		IdempotencyRepo:  pgrepo.NewIdempotencyRepo(db),
+		UserRepo:         audit.NewUserRepo(pgrepo.NewUserRepo(db), rec),
+		MembershipRepo:   audit.NewMembershipRepo(pgrepo.NewMembershipRepo(db), rec),
+		StatsRepo:        pgrepo.NewStatsRepo(db),
+		ChangeListener:   pgrepo.NewListener(db),
 	}
 }
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sunnyyssh/designing-software-cw1/internal/infrastructure/metrics"
+)
+
+type Metrics struct {
+	// Registerer labels every sample with the tenant.
+	Registerer  prometheus.Registerer
+	Instruments *metrics.Instruments
+	gatherer    prometheus.Gatherer
+}
+
+// NewMetrics registers process metrics, Instruments observe queries and service calls given to them.
+// Every sample is labelled with the tenant.
+func NewMetrics(pool *pgxpool.Pool, tenant string) *Metrics {
+	reg := prometheus.NewRegistry()
+	tenantReg := prometheus.WrapRegistererWith(prometheus.Labels{"tenant": tenant}, reg)
+	metrics.RegisterPool(tenantReg, pool)
+	ins := metrics.NewInstruments(tenantReg)
+	return &Metrics{
+		Registerer:  tenantReg,
+		Instruments: ins,
+		gatherer:    reg,
+	}
+}
+
+// Serve exposes /metrics on addr until ctx is done.
+func (m *Metrics) Serve(ctx context.Context, addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for metrics: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	// Metrics are gathered before anything is written, so a failed collection is a clean 500.
+	mux.Handle("/metrics", promhttp.HandlerFor(m.gatherer, promhttp.HandlerOpts{ErrorHandling: promhttp.HTTPErrorOnError}))
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	if err := srv.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("metrics server failed: %w", err)
+	}
+	return nil
+}
@@ -1,17 +1,51 @@
 package config
 
-import "github.com/sunnyyssh/designing-software-cw1/internal/application/services"
+import (
+	"time"
+
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/auth"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/services"
+	"github.com/sunnyyssh/designing-software-cw1/internal/infrastructure/webhook"
+	"github.com/sunnyyssh/designing-software-cw1/internal/settings"
+)
+
+const webhookTimeout = 10 * time.Second
 
 type Services struct {
 	BankAccountService *services.BankAccountService
 	OperationService   *services.OperationService
 	CategoryService    *services.CategoryService
+	BudgetService      *services.BudgetService
+	ScheduleService    *services.ScheduleService
+	AnalyticsService   *services.AnalyticsService
+	AuditService       *services.AuditService
+	OutboxService      *services.OutboxService
+	WatchService       *services.WatchService
+	StatementService   *services.StatementService
+	ForecastService    *services.ForecastService
+	UserService        *services.UserService
+	StatsService       *services.StatsService
 }
 
-func NewServices(dbConf *DB) *Services {
+// NewServices builds the services, observer is told about every service call and may be nil.
+func NewServices(dbConf *DB, set *settings.Settings, observer services.CallObserver) *Services {
+	authz := auth.NewAuthorizer(dbConf.MembershipRepo)
+	budgetSvc := services.NewBudgetService(dbConf.BudgetRepo, dbConf.CategoryRepo, dbConf.OperationRepo, authz, observer)
+	opSvc := services.NewOperationService(dbConf.Transactor, dbConf.BankAccountRepo, dbConf.OperationRepo, dbConf.CategoryRepo, dbConf.AccountEventRepo, dbConf.OutboxRepo, dbConf.IdempotencyRepo, budgetSvc, authz, set.MaxFutureDuration(), observer)
+	sender := webhook.NewSender(set.WebhookURLList(), set.WebhookSecret, webhookTimeout)
 	return &Services{
-		BankAccountService: services.NewBankAccountService(dbConf.BankAccountRepo),
-		OperationService:   services.NewOperationService(dbConf.BankAccountRepo, dbConf.OperationRepo),
-		CategoryService:    services.NewCategoryService(dbConf.CategoryRepo),
+		BankAccountService: services.NewBankAccountService(dbConf.Transactor, dbConf.BankAccountRepo, dbConf.AccountEventRepo, dbConf.UserRepo, dbConf.MembershipRepo, authz, observer),
+		OperationService:   opSvc,
+		CategoryService:    services.NewCategoryService(dbConf.Transactor, dbConf.CategoryRepo, dbConf.OperationRepo, dbConf.ScheduleRepo, observer),
+		BudgetService:      budgetSvc,
+		ScheduleService:    services.NewScheduleService(dbConf.Transactor, dbConf.ScheduleRepo, dbConf.BankAccountRepo, dbConf.CategoryRepo, opSvc, authz, observer),
+		AnalyticsService:   services.NewAnalyticsService(dbConf.CategoryRepo, dbConf.OperationRepo, authz, observer),
+		AuditService:       services.NewAuditService(dbConf.AuditRepo, authz, observer),
+		OutboxService:      services.NewOutboxService(dbConf.Transactor, dbConf.OutboxRepo, sender, observer),
+		WatchService:       services.NewWatchService(dbConf.ChangeListener, authz, observer),
+		StatementService:   services.NewStatementService(dbConf.Transactor, dbConf.BankAccountRepo, dbConf.OperationRepo, dbConf.CategoryRepo, authz, observer),
+		ForecastService:    services.NewForecastService(dbConf.BankAccountRepo, dbConf.OperationRepo, dbConf.CategoryRepo, dbConf.ScheduleRepo, authz, observer),
+		UserService:        services.NewUserService(dbConf.Transactor, dbConf.UserRepo, dbConf.BankAccountRepo, dbConf.MembershipRepo, observer),
+		StatsService:       services.NewStatsService(dbConf.StatsRepo, observer),
 	}
 }
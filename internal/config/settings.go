@@ -0,0 +1,32 @@
+package config
+
+import (
+	"io"
+
+	"github.com/spf13/pflag"
+	"github.com/sunnyyssh/designing-software-cw1/internal/settings"
+)
+
+// LoadSettings resolves settings before the command tree is built,
+// because the DB pool depends on the selected profile and tenant.
+func LoadSettings(args []string) (*settings.Settings, error) {
+	flags := pflag.NewFlagSet("bankcli", pflag.ContinueOnError)
+	flags.ParseErrorsWhitelist.UnknownFlags = true
+	flags.SetOutput(io.Discard)
+	flags.Usage = func() {}
+	profile := flags.String("profile", "", "")
+	tenant := flags.String("tenant", "", "")
+	// Everything except --profile and --tenant is parsed by cobra later.
+	_ = flags.Parse(args)
+
+	path, err := settings.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	file, err := settings.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return settings.Resolve(path, file, *profile, *tenant), nil
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/sunnyyssh/designing-software-cw1/internal/infrastructure/tracing"
+	"github.com/sunnyyssh/designing-software-cw1/internal/settings"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// traceShutdownTimeout bounds exporting the remaining spans on exit, e.g. to an unreachable collector.
+const traceShutdownTimeout = 5 * time.Second
+
+type Tracing struct {
+	Provider trace.TracerProvider
+	shutdown func(context.Context) error
+}
+
+// NewTracing sets up the global tracer provider exporting spans as set.TraceExporter says,
+// stdout spans are written to stderr to keep command output parseable.
+func NewTracing(ctx context.Context, set *settings.Settings) (*Tracing, error) {
+	exp, err := tracing.NewExporter(ctx, set.TraceExporter, os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if exp == nil {
+		return NoTracing(), nil
+	}
+	tp := tracing.NewProvider(exp, set.Tenant)
+	otel.SetTracerProvider(tp)
+	return &Tracing{Provider: tp, shutdown: tp.Shutdown}, nil
+}
+
+// NoTracing makes no spans.
+func NoTracing() *Tracing {
+	return &Tracing{
+		Provider: noop.NewTracerProvider(),
+		shutdown: func(context.Context) error { return nil },
+	}
+}
+
+// Shutdown exports the spans left.
+func (t *Tracing) Shutdown(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, traceShutdownTimeout)
+	defer cancel()
+	return t.shutdown(ctx)
+}
+
+// traceCommands makes every command run a root span named by its path, e.g. "bankcli operation transfer",
+// service and query spans are its children as cmd.Context() is passed down.
+func traceCommands(cmd *cobra.Command, tracer trace.Tracer) {
+	for _, c := range cmd.Commands() {
+		traceCommands(c, tracer)
+	}
+	run := cmd.RunE
+	if run == nil {
+		return
+	}
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx, span := tracer.Start(cmd.Context(), cmd.CommandPath())
+		cmd.SetContext(ctx)
+		err := run(cmd, args)
+		tracing.End(span, err)
+		return err
+	}
+}
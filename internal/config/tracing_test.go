@@ -0,0 +1,278 @@
+package config
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/spf13/cobra"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/auth"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/services"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+	"github.com/sunnyyssh/designing-software-cw1/internal/infrastructure/tracing"
+	"github.com/sunnyyssh/designing-software-cw1/internal/settings"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const testTenant = "tracing-test"
+
+var (
+	traceOnce     sync.Once
+	traceExporter *tracetest.InMemoryExporter
+	traceProvider *sdktrace.TracerProvider
+)
+
+// recordSpans makes spans go to an in-memory exporter and returns a func flushing and returning them.
+// The global provider is set once per process, services keep the first one they got.
+func recordSpans(t *testing.T) (*sdktrace.TracerProvider, func() tracetest.SpanStubs) {
+	t.Helper()
+	traceOnce.Do(func() {
+		traceExporter = tracetest.NewInMemoryExporter()
+		traceProvider = tracing.NewProvider(traceExporter, testTenant)
+		otel.SetTracerProvider(traceProvider)
+	})
+	flush := func() tracetest.SpanStubs {
+		t.Helper()
+		if err := traceProvider.ForceFlush(context.Background()); err != nil {
+			t.Fatalf("ForceFlush: %v", err)
+		}
+		return traceExporter.GetSpans()
+	}
+	flush()
+	traceExporter.Reset()
+	return traceProvider, flush
+}
+
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, s := range spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("no span %q in %v", name, spanNames(spans))
+	return tracetest.SpanStub{}
+}
+
+func spanNames(spans tracetest.SpanStubs) []string {
+	names := make([]string, 0, len(spans))
+	for _, s := range spans {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// childrenOf returns names of spans whose parent is s.
+func childrenOf(spans tracetest.SpanStubs, s tracetest.SpanStub) []string {
+	var names []string
+	for _, c := range spans {
+		if c.Parent.SpanID() == s.SpanContext.SpanID() {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// assertTransferNesting checks that the command span is a root
+// and the OperationService.Transfer span is its child.
+func assertTransferNesting(t *testing.T, spans tracetest.SpanStubs, command string) tracetest.SpanStub {
+	t.Helper()
+	cmd := findSpan(t, spans, command)
+	if cmd.Parent.IsValid() {
+		t.Errorf("command span has parent %s, want a root", cmd.Parent.SpanID())
+	}
+	transfer := findSpan(t, spans, "OperationService.Transfer")
+	if transfer.Parent.SpanID() != cmd.SpanContext.SpanID() {
+		t.Errorf("OperationService.Transfer parent = %s, want the command span %s",
+			transfer.Parent.SpanID(), cmd.SpanContext.SpanID())
+	}
+	if transfer.SpanContext.TraceID() != cmd.SpanContext.TraceID() {
+		t.Error("OperationService.Transfer is in another trace than the command")
+	}
+	return transfer
+}
+
+// spyAccounts keeps accounts in memory and remembers spans its calls were made in.
+type spyAccounts struct {
+	storage.BankAccountRepo
+	accounts map[uuid.UUID]*domain.BankAccount
+	spans    []trace.SpanContext
+}
+
+func (r *spyAccounts) GetForUpdate(ctx context.Context, id uuid.UUID) (*domain.BankAccount, error) {
+	r.spans = append(r.spans, trace.SpanContextFromContext(ctx))
+	acc, ok := r.accounts[id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return acc, nil
+}
+
+func (r *spyAccounts) Update(ctx context.Context, acc *domain.BankAccount) (*domain.BankAccount, error) {
+	r.spans = append(r.spans, trace.SpanContextFromContext(ctx))
+	return acc, nil
+}
+
+type memOperations struct{ storage.OperationRepo }
+
+func (memOperations) Create(_ context.Context, op *domain.Operation) (*domain.Operation, error) {
+	return op, nil
+}
+
+type memEvents struct{ storage.AccountEventRepo }
+
+func (memEvents) Append(context.Context, ...domain.AccountEvent) error { return nil }
+
+type memOutbox struct{ storage.OutboxRepo }
+
+func (memOutbox) Create(context.Context, *domain.OutboxMessage) error { return nil }
+
+// ownerOfAll makes every user the owner of every account.
+type ownerOfAll struct{ storage.MembershipRepo }
+
+func (ownerOfAll) Get(_ context.Context, accountID, userID uuid.UUID) (*domain.Membership, error) {
+	return &domain.Membership{AccountID: accountID, UserID: userID, Role: domain.RoleOwner}, nil
+}
+
+type noTx struct{}
+
+func (noTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func newAccount(t *testing.T, balance int64) *domain.BankAccount {
+	t.Helper()
+	acc, err := domain.NewBankAccount("test", "RUB", domain.AccountTypeDebit, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc.Balance = balance
+	return acc
+}
+
+func TestTransferSpanIsChildOfCommand(t *testing.T) {
+	tp, flush := recordSpans(t)
+
+	from, to := newAccount(t, 100), newAccount(t, 0)
+	accounts := &spyAccounts{accounts: map[uuid.UUID]*domain.BankAccount{from.ID: from, to.ID: to}}
+	opSvc := services.NewOperationService(noTx{}, accounts, memOperations{}, nil, memEvents{}, memOutbox{}, nil,
+		nil, auth.NewAuthorizer(ownerOfAll{}), 0, nil)
+
+	root := &cobra.Command{Use: "bankcli"}
+	transferCmd := &cobra.Command{
+		Use: "transfer",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := opSvc.Transfer(cmd.Context(), services.TransferRequest{
+				FromAccountID: from.ID,
+				ToAccountID:   to.ID,
+				Amount:        40,
+			})
+			return err
+		},
+	}
+	root.AddCommand(transferCmd)
+	traceCommands(root, tp.Tracer("test"))
+
+	user := &domain.User{ID: uuid.New(), Name: "alice"}
+	root.SetArgs([]string{"transfer"})
+	if err := root.ExecuteContext(auth.WithUser(context.Background(), user)); err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+
+	spans := flush()
+	transfer := assertTransferNesting(t, spans, "bankcli transfer")
+	// Repositories get the service span, which query spans are made under.
+	if len(accounts.spans) == 0 {
+		t.Fatal("accounts were not touched")
+	}
+	for _, sc := range accounts.spans {
+		if sc.SpanID() != transfer.SpanContext.SpanID() {
+			t.Errorf("repository called in span %s, want OperationService.Transfer %s", sc.SpanID(), transfer.SpanContext.SpanID())
+		}
+	}
+}
+
+// TestTransferQuerySpans runs `operation transfer` against the database in BANKCLI_TEST_PG_CONN_STRING,
+// it's skipped without it. The database must be migrated and the role must not bypass row-level security.
+func TestTransferQuerySpans(t *testing.T) {
+	connString := os.Getenv("BANKCLI_TEST_PG_CONN_STRING")
+	if connString == "" {
+		t.Skip("BANKCLI_TEST_PG_CONN_STRING is not set")
+	}
+	tp, flush := recordSpans(t)
+	ctx := context.Background()
+
+	poolConf, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	poolConf.ConnConfig.Tracer = tracing.NewQueryTracer(tp)
+	pool, err := pgxpool.NewWithConfig(ctx, poolConf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	// A fresh tenant has no users, so the test user doesn't adopt accounts of earlier runs.
+	set := settings.Resolve("", &settings.File{}, "", "test-"+uuid.NewString())
+	set.ConnString = connString
+	svc := NewServices(NewDB(pool, set.Tenant, "test", nil), set, nil)
+
+	user, err := svc.UserService.Create(ctx, "alice")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	userCtx, err := svc.UserService.Authenticate(ctx, user.Token)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	var ids []string
+	for _, name := range []string{"from", "to"} {
+		acc, err := svc.BankAccountService.CreateAccount(userCtx, services.CreateAccountRequest{
+			Name: name, Currency: "RUB", Type: string(domain.AccountTypeDebit),
+		})
+		if err != nil {
+			t.Fatalf("create account: %v", err)
+		}
+		ids = append(ids, acc.ID.String())
+	}
+	_, err = svc.OperationService.ApplyOperation(userCtx, services.ApplyOperationRequest{
+		AccountID:     uuid.MustParse(ids[0]),
+		Amount:        100,
+		OperationType: string(domain.OperationTypeIncome),
+	})
+	if err != nil {
+		t.Fatalf("income: %v", err)
+	}
+	flush()
+	traceExporter.Reset()
+
+	set.APIToken = user.Token
+	cmd := CLI(svc, NewMetrics(pool, set.Tenant), &Tracing{Provider: tp, shutdown: tp.Shutdown}, set)
+	cmd.SetArgs([]string{"operation", "transfer", "-f", ids[0], "-t", ids[1], "-m", "40"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	if err := cmd.ExecuteContext(ctx); err != nil {
+		t.Fatalf("operation transfer: %v", err)
+	}
+
+	spans := flush()
+	transfer := assertTransferNesting(t, spans, "bankcli operation transfer")
+	queries := make(map[string]bool)
+	for _, name := range childrenOf(spans, transfer) {
+		queries[name] = true
+	}
+	for _, want := range []string{"SELECT", "UPDATE", "INSERT"} {
+		if !queries[want] {
+			t.Errorf("OperationService.Transfer children = %v, want a %s query span", childrenOf(spans, transfer), want)
+		}
+	}
+}
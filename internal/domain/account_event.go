@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AccountEventType string
+
+const (
+	EventAccountCreated    AccountEventType = "AccountCreated"
+	EventAccountBlocked    AccountEventType = "AccountBlocked"
+	EventAccountUnblocked  AccountEventType = "AccountUnblocked"
+	EventOverdraftLimitSet AccountEventType = "OverdraftLimitSet"
+	EventOperationApplied  AccountEventType = "OperationApplied"
+	EventAccountDeleted    AccountEventType = "AccountDeleted"
+	EventAccountRestored   AccountEventType = "AccountRestored"
+)
+
+// AccountEvent is a change of a bank account. Replaying all events of an account
+// in order gives its state at the time of the last one.
+type AccountEvent struct {
+	ID        uuid.UUID
+	AccountID uuid.UUID
+	Type      AccountEventType
+	Time      time.Time
+	Data      AccountEventData
+}
+
+// AccountEventData is the payload, only fields relevant to the event type are set.
+type AccountEventData struct {
+	// AccountCreated
+	Name        string
+	AccountType AccountType
+	Currency    string
+	// Balance is the opening balance, non-zero only for accounts that existed before the event store.
+	Balance int64
+
+	// AccountCreated and OverdraftLimitSet
+	OverdraftLimit int64
+
+	// OperationApplied
+	OperationID   uuid.UUID
+	OperationType OperationType
+	Amount        int64
+	// OperationTime is when the operation was made, it is earlier than the event for backdated ones.
+	OperationTime time.Time
+}
+
+func (a *BankAccount) record(typ AccountEventType, data AccountEventData) {
+	a.events = append(a.events, AccountEvent{
+		ID:        uuid.New(),
+		AccountID: a.ID,
+		Type:      typ,
+		Time:      TimeFunc(),
+		Data:      data,
+	})
+}
+
+// EffectiveTime is when the event changed the account: the operation time for applied operations,
+// otherwise the time the event was recorded.
+func (e *AccountEvent) EffectiveTime() time.Time {
+	if e.Type == EventOperationApplied && !e.Data.OperationTime.IsZero() {
+		return e.Data.OperationTime
+	}
+	return e.Time
+}
+
+// EventsAsOf keeps events that changed the account by the given time, in their order.
+// The history starts with the earliest of them, so the AccountCreated event is kept once any event is:
+// an operation can be backdated before it was recorded, e.g. to the real creation time
+// of an account that existed before the event store and starts from a snapshot.
+func EventsAsOf(events []AccountEvent, at time.Time) []AccountEvent {
+	started := false
+	for _, e := range events {
+		if !e.EffectiveTime().After(at) {
+			started = true
+			break
+		}
+	}
+	if !started {
+		return nil
+	}
+
+	var kept []AccountEvent
+	for _, e := range events {
+		if e.Type == EventAccountCreated || !e.EffectiveTime().After(at) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// PullEvents returns events recorded since the last call and forgets them.
+// They should be saved together with the account.
+func (a *BankAccount) PullEvents() []AccountEvent {
+	events := a.events
+	a.events = nil
+	return events
+}
+
+// ReplayBankAccount rebuilds an account from its events. deleted reports whether
+// the account was closed after the events, the account then has its state at closing.
+func ReplayBankAccount(events []AccountEvent) (acc *BankAccount, deleted bool, err error) {
+	if len(events) == 0 || events[0].Type != EventAccountCreated {
+		return nil, false, ErrNoAccountHistory
+	}
+
+	acc = &BankAccount{}
+	for _, e := range events {
+		switch e.Type {
+		case EventAccountCreated:
+			acc.ID = e.AccountID
+			acc.Name = e.Data.Name
+			acc.Type = e.Data.AccountType
+			acc.Currency = e.Data.Currency
+			acc.Balance = e.Data.Balance
+			acc.OverdraftLimit = e.Data.OverdraftLimit
+			acc.CreatedAt = e.Time
+		case EventAccountBlocked:
+			acc.Blocked = true
+		case EventAccountUnblocked:
+			acc.Blocked = false
+		case EventOverdraftLimitSet:
+			acc.OverdraftLimit = e.Data.OverdraftLimit
+		case EventOperationApplied:
+			switch e.Data.OperationType {
+			case OperationTypeIncome:
+				acc.Balance += e.Data.Amount
+			case OperationTypeOutcome:
+				acc.Balance -= e.Data.Amount
+			}
+		case EventAccountDeleted:
+			deleted = true
+			acc.ClosedAt = &e.Time
+		case EventAccountRestored:
+			deleted = false
+			acc.ClosedAt = nil
+		default:
+			return nil, false, ErrUnknownAccountEvent
+		}
+	}
+	return acc, deleted, nil
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReplayOperationBackdatedBeforeCreatedEvent(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	// The account existed before the event store, its AccountCreated event is a later snapshot.
+	acc, err := NewBankAccount("Main", "RUB", AccountTypeDebit, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc.CreatedAt = created
+	events := acc.PullEvents()
+	events[0].Time = created.Add(30 * 24 * time.Hour)
+	events[0].Data.Balance = 1000
+
+	op, err := ApplyOperationAt(acc, OperationTypeIncome, 200, "", created.Add(24*time.Hour), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events = append(events, acc.PullEvents()...)
+
+	if _, _, err := ReplayBankAccount(EventsAsOf(events, created)); !errors.Is(err, ErrNoAccountHistory) {
+		t.Errorf("before the earliest event: err = %v, want ErrNoAccountHistory", err)
+	}
+	for _, at := range []time.Time{op.Time, events[0].Time, events[1].Time} {
+		replayed, _, err := ReplayBankAccount(EventsAsOf(events, at))
+		if err != nil {
+			t.Fatalf("as of %v: %v", at, err)
+		}
+		if replayed.Balance != 1200 {
+			t.Errorf("as of %v: balance = %d, want 1200", at, replayed.Balance)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AnomalyKind string
+
+const (
+	// AnomalyAmount is an amount far above the median of recent operations in the same category.
+	AnomalyAmount AnomalyKind = "amount"
+	// AnomalyHour is an operation at an hour the account is normally not used at.
+	AnomalyHour AnomalyKind = "hour"
+	// AnomalyDuplicate repeats the amount and description of an operation made minutes earlier.
+	AnomalyDuplicate AnomalyKind = "duplicate"
+)
+
+// AnomalyRules are the thresholds of DetectAnomalies.
+type AnomalyRules struct {
+	// MedianWindow is how many previous operations of a category and account the median is taken over,
+	// at least MinHistory of them are needed to judge an amount.
+	MedianWindow int
+	MinHistory   int
+	// AmountFactor is how many times the median an amount must exceed.
+	AmountFactor float64
+	// HourMinHistory previous operations of the account are needed to judge the hour.
+	// An hour is unusual if no previous operation was within an hour of it.
+	HourMinHistory int
+	// DuplicateWindow is how close in time repeated operations count as duplicates.
+	DuplicateWindow time.Duration
+}
+
+var DefaultAnomalyRules = AnomalyRules{
+	MedianWindow:    30,
+	MinHistory:      5,
+	AmountFactor:    3,
+	HourMinHistory:  20,
+	DuplicateWindow: DuplicateWindow,
+}
+
+type AnomalyReason struct {
+	Kind   AnomalyKind
+	Detail string
+}
+
+type Anomaly struct {
+	Operation Operation
+	Reasons   []AnomalyReason
+}
+
+// DetectAnomalies checks operations made since the given time against the ones before them.
+// ops must be in chronological order and include the history to compare with.
+func DetectAnomalies(ops []Operation, since time.Time, rules AnomalyRules) []Anomaly {
+	type groupKey struct {
+		accountID  uuid.UUID
+		typ        OperationType
+		categoryID uuid.UUID
+	}
+	amounts := make(map[groupKey][]int64)
+	hours := make(map[uuid.UUID]*[24]int)
+	seen := make(map[uuid.UUID]int)
+
+	var anomalies []Anomaly
+	for i, op := range ops {
+		key := groupKey{accountID: op.AccountID, typ: op.Type}
+		if op.CategoryID != nil {
+			key.categoryID = *op.CategoryID
+		}
+		if hours[op.AccountID] == nil {
+			hours[op.AccountID] = new([24]int)
+		}
+		hour := op.Time.Local().Hour()
+
+		if !op.Time.Before(since) {
+			var reasons []AnomalyReason
+			if r, ok := amountAnomaly(op, amounts[key], rules); ok {
+				reasons = append(reasons, r)
+			}
+			if r, ok := hourAnomaly(hours[op.AccountID], seen[op.AccountID], hour, rules); ok {
+				reasons = append(reasons, r)
+			}
+			if r, ok := duplicateAnomaly(ops[:i], op, rules); ok {
+				reasons = append(reasons, r)
+			}
+			if len(reasons) > 0 {
+				anomalies = append(anomalies, Anomaly{Operation: op, Reasons: reasons})
+			}
+		}
+
+		amounts[key] = append(amounts[key], op.Amount)
+		if len(amounts[key]) > rules.MedianWindow {
+			amounts[key] = amounts[key][1:]
+		}
+		hours[op.AccountID][hour]++
+		seen[op.AccountID]++
+	}
+	return anomalies
+}
+
+func amountAnomaly(op Operation, previous []int64, rules AnomalyRules) (AnomalyReason, bool) {
+	if len(previous) < rules.MinHistory {
+		return AnomalyReason{}, false
+	}
+	median := medianOf(previous)
+	if median <= 0 || float64(op.Amount) <= rules.AmountFactor*median {
+		return AnomalyReason{}, false
+	}
+	return AnomalyReason{
+		Kind:   AnomalyAmount,
+		Detail: fmt.Sprintf("amount %d is %.1fx the median %.0f of %d previous operations", op.Amount, float64(op.Amount)/median, median, len(previous)),
+	}, true
+}
+
+func hourAnomaly(hours *[24]int, total, hour int, rules AnomalyRules) (AnomalyReason, bool) {
+	if total < rules.HourMinHistory {
+		return AnomalyReason{}, false
+	}
+	if hours[(hour+23)%24]+hours[hour]+hours[(hour+1)%24] > 0 {
+		return AnomalyReason{}, false
+	}
+	return AnomalyReason{
+		Kind:   AnomalyHour,
+		Detail: fmt.Sprintf("none of %d previous operations of the account was made around %02d:00", total, hour),
+	}, true
+}
+
+// duplicateAnomaly looks back through previous operations while they are within the window.
+func duplicateAnomaly(previous []Operation, op Operation, rules AnomalyRules) (AnomalyReason, bool) {
+	for i := len(previous) - 1; i >= 0; i-- {
+		p := previous[i]
+		if op.Time.Sub(p.Time) > rules.DuplicateWindow {
+			break
+		}
+		if p.AccountID == op.AccountID && p.Type == op.Type && p.Amount == op.Amount &&
+			similarDescriptions(p.Description, op.Description) {
+			return AnomalyReason{
+				Kind:   AnomalyDuplicate,
+				Detail: fmt.Sprintf("same amount and description as operation %s made %s earlier", p.ID, op.Time.Sub(p.Time).Round(time.Second)),
+			}, true
+		}
+	}
+	return AnomalyReason{}, false
+}
+
+func medianOf(values []int64) float64 {
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	n := len(sorted)
+	if n%2 == 1 {
+		return float64(sorted[n/2])
+	}
+	return float64(sorted[n/2-1]+sorted[n/2]) / 2
+}
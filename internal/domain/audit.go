@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditAction string
+
+const (
+	AuditActionCreate   AuditAction = "create"
+	AuditActionUpdate   AuditAction = "update"
+	AuditActionDelete   AuditAction = "delete"
+	AuditActionReassign AuditAction = "reassign"
+)
+
+type EntityType string
+
+const (
+	EntityBankAccount EntityType = "account"
+	EntityCategory    EntityType = "category"
+	EntityOperation   EntityType = "operation"
+	EntityBudget      EntityType = "budget"
+	EntitySchedule    EntityType = "schedule"
+	// EntityMember entries are recorded on the account, their snapshots name the user.
+	EntityMember EntityType = "member"
+	EntityUser   EntityType = "user"
+)
+
+var EntityTypes = []EntityType{EntityBankAccount, EntityCategory, EntityOperation, EntityBudget, EntitySchedule, EntityMember, EntityUser}
+
+// AuditEntry records a single state change. Before is empty for creations, After for deletions.
+type AuditEntry struct {
+	ID         uuid.UUID
+	Actor      string
+	Action     AuditAction
+	EntityType EntityType
+	EntityID   uuid.UUID
+	Before     json.RawMessage
+	After      json.RawMessage
+	Time       time.Time
+}
+
+func NewAuditEntry(actor string, action AuditAction, entityType EntityType, entityID uuid.UUID, before, after any) (*AuditEntry, error) {
+	entry := &AuditEntry{
+		ID:         uuid.New(),
+		Actor:      actor,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Time:       TimeFunc(),
+	}
+
+	var err error
+	if entry.Before, err = snapshot(before); err != nil {
+		return nil, err
+	}
+	if entry.After, err = snapshot(after); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func snapshot(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
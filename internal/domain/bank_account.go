@@ -1,47 +1,162 @@
 package domain
 
 import (
+	"strings"
+	"time"
+
 	"github.com/google/uuid"
 )
 
+type AccountType string
+
+const (
+	AccountTypeDebit   AccountType = "debit"
+	AccountTypeCredit  AccountType = "credit"
+	AccountTypeSavings AccountType = "savings"
+)
+
 type BankAccount struct {
-	ID      uuid.UUID
-	Name    string
-	Balance int64
-	Blocked bool
+	ID       uuid.UUID
+	Name     string
+	Type     AccountType
+	Currency string
+	Balance  int64
+	// OverdraftLimit is how far below zero the balance may go.
+	OverdraftLimit int64
+	Blocked        bool
+	// ClosedAt is set for deleted accounts, they are kept for the history of their operations.
+	ClosedAt *time.Time
+	// CreatedAt bounds the time of operations entered afterwards.
+	CreatedAt time.Time
+
+	// events are recorded changes not saved yet, see PullEvents.
+	events []AccountEvent
 }
 
-func NewBankAccount(name string) (*BankAccount, error) {
+func NewBankAccount(name string, currency string, typ AccountType, overdraftLimit int64) (*BankAccount, error) {
 	if name == "" {
 		return nil, ErrEmptyName
 	}
-	return &BankAccount{
-		ID:      uuid.New(), // It should be set in the database creation
-		Name:    name,
-		Balance: 0,
-		Blocked: false,
-	}, nil
+	switch typ {
+	case AccountTypeDebit, AccountTypeCredit, AccountTypeSavings:
+	default:
+		return nil, ErrUnknownAccountType
+	}
+	currency, err := ParseCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	acc := &BankAccount{
+		ID:        uuid.New(), // It should be set in the database creation
+		Name:      name,
+		Type:      typ,
+		Currency:  currency,
+		Balance:   0,
+		Blocked:   false,
+		CreatedAt: TimeFunc(),
+	}
+	if err := acc.setOverdraftLimit(overdraftLimit); err != nil {
+		return nil, err
+	}
+	acc.record(EventAccountCreated, AccountEventData{
+		Name:           acc.Name,
+		AccountType:    acc.Type,
+		Currency:       acc.Currency,
+		OverdraftLimit: acc.OverdraftLimit,
+	})
+	return acc, nil
+}
+
+// SetOverdraftLimit changes the limit. It can't be lowered below the current debt.
+func (a *BankAccount) SetOverdraftLimit(limit int64) error {
+	if a.ClosedAt != nil {
+		return ErrAccountClosed
+	}
+	if err := a.setOverdraftLimit(limit); err != nil {
+		return err
+	}
+	a.record(EventOverdraftLimitSet, AccountEventData{OverdraftLimit: limit})
+	return nil
+}
+
+func (a *BankAccount) setOverdraftLimit(limit int64) error {
+	if limit < 0 {
+		return ErrNegativeOverdraft
+	}
+	if limit > 0 && a.Type == AccountTypeSavings {
+		return ErrOverdraftNotAllowed
+	}
+	if a.Balance < -limit {
+		return ErrOverdraftBelowDebt
+	}
+	a.OverdraftLimit = limit
+	return nil
+}
+
+// AvailableToSpend is the balance plus the unused part of the overdraft.
+func (a *BankAccount) AvailableToSpend() int64 {
+	return a.Balance + a.OverdraftLimit
+}
+
+// ParseCurrency normalizes an ISO 4217 alphabetic code like "usd" to "USD".
+func ParseCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return "", ErrInvalidCurrency
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return "", ErrInvalidCurrency
+		}
+	}
+	return code, nil
 }
 
 func (a *BankAccount) Block() error {
+	if a.ClosedAt != nil {
+		return ErrAccountClosed
+	}
 	if a.Blocked {
 		return ErrAlreadyBlocked
 	}
 	a.Blocked = true
+	a.record(EventAccountBlocked, AccountEventData{})
 	return nil
 }
 
 func (a *BankAccount) Unblock() error {
+	if a.ClosedAt != nil {
+		return ErrAccountClosed
+	}
 	if !a.Blocked {
 		return ErrAlreadyUnblocked
 	}
 	a.Blocked = false
+	a.record(EventAccountUnblocked, AccountEventData{})
 	return nil
 }
 
+// Delete checks that the account can be closed, only an empty account can.
 func (a *BankAccount) Delete() error {
-	if a.Balance != 0 {
+	if a.ClosedAt != nil {
+		return ErrAccountClosed
+	}
+	if a.Balance > 0 {
 		return ErrAccountHasPositiveBalance
 	}
+	if a.Balance < 0 {
+		return ErrAccountHasDebt
+	}
+	a.record(EventAccountDeleted, AccountEventData{})
+	return nil
+}
+
+// Restore reopens a closed account.
+func (a *BankAccount) Restore() error {
+	if a.ClosedAt == nil {
+		return ErrAccountNotClosed
+	}
+	a.ClosedAt = nil
+	a.record(EventAccountRestored, AccountEventData{})
 	return nil
 }
@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type BudgetPeriod string
+
+const (
+	BudgetPeriodMonthly BudgetPeriod = "monthly"
+	BudgetPeriodWeekly  BudgetPeriod = "weekly"
+)
+
+type Budget struct {
+	ID         uuid.UUID
+	CategoryID uuid.UUID
+	Period     BudgetPeriod
+	Limit      int64
+}
+
+func NewBudget(cat *Category, period BudgetPeriod, limit int64) (*Budget, error) {
+	if cat.DeletedAt != nil {
+		return nil, ErrCategoryDeleted
+	}
+	if cat.Type != CategoryTypeOutcome {
+		return nil, ErrBudgetOnIncomeCategory
+	}
+	if period != BudgetPeriodMonthly && period != BudgetPeriodWeekly {
+		return nil, ErrUnknownBudgetPeriod
+	}
+	b := &Budget{
+		ID:         uuid.New(),
+		CategoryID: cat.ID,
+		Period:     period,
+	}
+	if err := b.SetLimit(limit); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *Budget) SetLimit(limit int64) error {
+	if limit <= 0 {
+		return ErrNonPositiveLimit
+	}
+	b.Limit = limit
+	return nil
+}
+
+// PeriodBounds returns the half-open interval [from, to) of the period containing t.
+// Weeks start on Monday.
+func (b *Budget) PeriodBounds(t time.Time) (from, to time.Time) {
+	y, m, d := t.Date()
+	switch b.Period {
+	case BudgetPeriodWeekly:
+		offset := (int(t.Weekday()) + 6) % 7
+		from = time.Date(y, m, d-offset, 0, 0, 0, 0, t.Location())
+		return from, from.AddDate(0, 0, 7)
+	default:
+		from = time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+		return from, from.AddDate(0, 1, 0)
+	}
+}
@@ -1,6 +1,10 @@
 package domain
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type CategoryType string
 
@@ -10,14 +14,21 @@ const (
 )
 
 type Category struct {
-	ID   uuid.UUID
-	Type CategoryType
-	Name string
+	ID       uuid.UUID
+	Type     CategoryType
+	Name     string
+	ParentID *uuid.UUID
+	// DeletedAt is set for deleted categories, they stay referenced by past operations.
+	DeletedAt *time.Time
 }
 
 func NewCategory(typ CategoryType, name string) (*Category, error) {
-	if typ == "" {
+	switch typ {
+	case "":
 		return nil, ErrEmptyType
+	case CategoryTypeIncome, CategoryTypeOutcome:
+	default:
+		return nil, ErrUnknownCategoryType
 	}
 	if name == "" {
 		return nil, ErrEmptyName
@@ -28,3 +39,133 @@ func NewCategory(typ CategoryType, name string) (*Category, error) {
 		Name: name,
 	}, nil
 }
+
+// NewSubcategory creates a child of parent. An empty type is inherited from the parent.
+func NewSubcategory(parent *Category, typ CategoryType, name string) (*Category, error) {
+	if parent.DeletedAt != nil {
+		return nil, ErrParentDeleted
+	}
+	if typ == "" {
+		typ = parent.Type
+	}
+	cat, err := NewCategory(typ, name)
+	if err != nil {
+		return nil, err
+	}
+	if cat.Type != parent.Type {
+		return nil, ErrParentTypeMismatch
+	}
+	cat.ParentID = &parent.ID
+	return cat, nil
+}
+
+func (c *Category) Rename(name string) error {
+	if name == "" {
+		return ErrEmptyName
+	}
+	c.Name = name
+	return nil
+}
+
+// CanMergeInto checks that all operations and subcategories of c can be moved to into.
+func (c *Category) CanMergeInto(into *Category, all []Category) error {
+	if c.ID == into.ID {
+		return ErrMergeIntoItself
+	}
+	if c.DeletedAt != nil || into.DeletedAt != nil {
+		return ErrCategoryDeleted
+	}
+	if c.Type != into.Type {
+		return ErrCategoryTypeMismatch
+	}
+	for _, id := range CategoryPath(all, into.ID) {
+		if id == c.ID {
+			return ErrCategoryCycle
+		}
+	}
+	return nil
+}
+
+// SetParent moves the category under parent, or makes it a root if parent is nil.
+// all must contain every category, it's used to detect cycles.
+func (c *Category) SetParent(parent *Category, all []Category) error {
+	if c.DeletedAt != nil {
+		return ErrCategoryDeleted
+	}
+	if parent == nil {
+		c.ParentID = nil
+		return nil
+	}
+	if parent.DeletedAt != nil {
+		return ErrParentDeleted
+	}
+	if parent.Type != c.Type {
+		return ErrParentTypeMismatch
+	}
+	for _, id := range CategoryPath(all, parent.ID) {
+		if id == c.ID {
+			return ErrCategoryCycle
+		}
+	}
+	c.ParentID = &parent.ID
+	return nil
+}
+
+// Delete checks that the category isn't deleted yet.
+func (c *Category) Delete() error {
+	if c.DeletedAt != nil {
+		return ErrCategoryDeleted
+	}
+	return nil
+}
+
+// Restore undeletes the category under its former parent, which must not be deleted.
+// parent is nil for a root category.
+func (c *Category) Restore(parent *Category) error {
+	if c.DeletedAt == nil {
+		return ErrCategoryNotDeleted
+	}
+	if parent != nil && parent.DeletedAt != nil {
+		return ErrParentDeleted
+	}
+	c.DeletedAt = nil
+	return nil
+}
+
+// CategoryPath returns IDs from the category up to its root, the category itself included.
+func CategoryPath(all []Category, id uuid.UUID) []uuid.UUID {
+	parents := make(map[uuid.UUID]*uuid.UUID, len(all))
+	for _, c := range all {
+		parents[c.ID] = c.ParentID
+	}
+
+	path := []uuid.UUID{id}
+	seen := map[uuid.UUID]bool{id: true}
+	for p := parents[id]; p != nil && !seen[*p]; p = parents[*p] {
+		path = append(path, *p)
+		seen[*p] = true
+	}
+	return path
+}
+
+// CategorySubtree returns IDs of the category and all its descendants.
+func CategorySubtree(all []Category, id uuid.UUID) []uuid.UUID {
+	children := make(map[uuid.UUID][]uuid.UUID, len(all))
+	for _, c := range all {
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c.ID)
+		}
+	}
+
+	subtree := []uuid.UUID{id}
+	seen := map[uuid.UUID]bool{id: true}
+	for i := 0; i < len(subtree); i++ {
+		for _, child := range children[subtree[i]] {
+			if !seen[child] {
+				subtree = append(subtree, child)
+				seen[child] = true
+			}
+		}
+	}
+	return subtree
+}
@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// DuplicateWindow is how close in time two operations must be to look like one entered twice.
+const DuplicateWindow = 5 * time.Minute
+
+// ProbableDuplicateOf reports whether o looks like p entered again: the same account, type
+// and amount within DuplicateWindow of it, and a similar description.
+func (o *Operation) ProbableDuplicateOf(p *Operation) bool {
+	if o.ID == p.ID || o.AccountID != p.AccountID || o.Type != p.Type || o.Amount != p.Amount {
+		return false
+	}
+	if d := o.Time.Sub(p.Time); d > DuplicateWindow || d < -DuplicateWindow {
+		return false
+	}
+	return similarDescriptions(o.Description, p.Description)
+}
+
+// similarDescriptions ignores case and spacing, and treats a description
+// extending the other one, e.g. "Coffee" and "coffee at the station", as similar.
+func similarDescriptions(a, b string) bool {
+	a = strings.Join(strings.Fields(strings.ToLower(a)), " ")
+	b = strings.Join(strings.Fields(strings.ToLower(b)), " ")
+	if a == "" || b == "" {
+		return a == b
+	}
+	return strings.Contains(a, b) || strings.Contains(b, a)
+}
@@ -16,4 +16,48 @@ var (
 	ErrNotEnoughMoney            = &Error{"not enough money. Work harder bro"}
 	ErrCannotResolveCategory     = &Error{"cannot resolve category"}
 	ErrAccountHasPositiveBalance = &Error{"account has a positive balance"}
+	ErrInvalidCurrency           = &Error{"invalid currency code"}
+	ErrCurrencyMismatch          = &Error{"accounts have different currencies"}
+	ErrCategoryTypeMismatch      = &Error{"category type doesn't match operation type"}
+	ErrBudgetOnIncomeCategory    = &Error{"budgets can be set only on outcome categories"}
+	ErrUnknownBudgetPeriod       = &Error{"unknown budget period"}
+	ErrNonPositiveLimit          = &Error{"limit must be positive"}
+	ErrInvalidPeriod             = &Error{"end of the period must be after its start"}
+	ErrBudgetExceeded            = &Error{"budget exceeded"}
+	ErrUnknownOperationType      = &Error{"unknown operation type"}
+	ErrNonPositiveAmount         = &Error{"amount must be positive"}
+	ErrInvalidRecurrence         = &Error{"invalid recurrence rule"}
+	ErrSameAccount               = &Error{"cannot transfer to the same account"}
+	ErrProbableDuplicate         = &Error{"probable duplicate"}
+	ErrOperationBeforeAccount    = &Error{"operation time is before the account was created"}
+	ErrOperationTooFarInFuture   = &Error{"operation time is too far in the future"}
+	ErrUnauthenticated           = &Error{"not authenticated, set an API token with `bankcli config set api_token <token>`"}
+	ErrForbidden                 = &Error{"not allowed for your role on the account"}
+	ErrInvalidToken              = &Error{"invalid API token"}
+	ErrUnknownRole               = &Error{"unknown role, expected owner, editor or viewer"}
+	ErrLastOwner                 = &Error{"account must keep at least one owner"}
+	ErrUnknownAccountType        = &Error{"unknown account type"}
+	ErrUnknownCategoryType       = &Error{"unknown category type"}
+	ErrNegativeOverdraft         = &Error{"overdraft limit can't be negative"}
+	ErrOverdraftNotAllowed       = &Error{"savings accounts can't have an overdraft"}
+	ErrOverdraftBelowDebt        = &Error{"overdraft limit is lower than the current debt"}
+	ErrAccountHasDebt            = &Error{"account has a negative balance"}
+	ErrCategoryCycle             = &Error{"category can't be moved under its own descendant"}
+	ErrParentTypeMismatch        = &Error{"category type doesn't match parent category type"}
+	ErrMergeIntoItself           = &Error{"category can't be merged into itself"}
+	ErrCategoryInUse             = &Error{"category is used by operations"}
+	ErrInvalidTag                = &Error{"tag must be non-empty and contain no spaces or commas"}
+	ErrSplitSumMismatch          = &Error{"split amounts must sum to the operation amount"}
+	ErrDuplicateSplitCategory    = &Error{"category is used twice in splits"}
+	ErrUnknownEntityType         = &Error{"unknown entity type"}
+	ErrNoAccountHistory          = &Error{"account has no history at that time"}
+	ErrUnknownAccountEvent       = &Error{"unknown account event"}
+	ErrOutboxNotDead             = &Error{"only dead outbox messages can be requeued"}
+	ErrInvalidIdempotencyKey     = &Error{"idempotency key must be 1 to 255 characters long"}
+	ErrAccountClosed             = &Error{"account is closed"}
+	ErrAccountNotClosed          = &Error{"account is not closed"}
+	ErrCategoryDeleted           = &Error{"category is deleted"}
+	ErrCategoryNotDeleted        = &Error{"category is not deleted"}
+	ErrParentDeleted             = &Error{"parent category is deleted, restore it first"}
+	ErrIdempotencyKeyReused      = &Error{"idempotency key was already used for a different request"}
 )
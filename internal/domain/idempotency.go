@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const maxIdempotencyKeyLen = 255
+
+// IdempotencyKey remembers the result of a request, so that a retried request
+// with the same key returns it instead of being applied again.
+type IdempotencyKey struct {
+	// UserID is the user the key belongs to, keys of different users don't collide.
+	UserID uuid.UUID
+	Key    string
+	// Fingerprint identifies the request the key was first used with.
+	Fingerprint string
+	Response    json.RawMessage
+	CreatedAt   time.Time
+}
+
+// NewIdempotencyKey fingerprints request of the given kind, e.g. "operation.apply", made by the user.
+func NewIdempotencyKey(userID uuid.UUID, key, kind string, request any) (*IdempotencyKey, error) {
+	if key == "" || len(key) > maxIdempotencyKeyLen {
+		return nil, ErrInvalidIdempotencyKey
+	}
+	raw, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(raw)
+	return &IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: kind + ":" + hex.EncodeToString(sum[:]),
+		CreatedAt:   TimeFunc(),
+	}, nil
+}
+
+// Replay decodes the remembered response into resp. A key can't be reused for another request.
+func (k *IdempotencyKey) Replay(request *IdempotencyKey, resp any) error {
+	if k.Fingerprint != request.Fingerprint {
+		return ErrIdempotencyKeyReused
+	}
+	return json.Unmarshal(k.Response, resp)
+}
+
+func (k *IdempotencyKey) SetResponse(resp any) error {
+	raw, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	k.Response = raw
+	return nil
+}
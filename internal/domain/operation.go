@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -25,7 +26,59 @@ type Operation struct {
 	Time        time.Time
 	Description string
 	CategoryID  *uuid.UUID
-	applied     bool
+	// Tags are normalized, sorted and unique.
+	Tags []string
+	// Splits divide the amount between categories, they are empty for an unsplit operation.
+	Splits  []Split
+	applied bool
+}
+
+type Split struct {
+	// CategoryID is nil if the category was deleted.
+	CategoryID *uuid.UUID
+	Amount     int64
+}
+
+type SplitPart struct {
+	Category *Category
+	Amount   int64
+}
+
+// SetSplits divides the operation between categories. Amounts must be positive and sum to the
+// operation amount, categories must be distinct and match the operation type.
+// Empty parts remove the splits.
+func (o *Operation) SetSplits(parts []SplitPart) error {
+	typ, err := ResolveCategoryType(o)
+	if err != nil {
+		return err
+	}
+
+	splits := make([]Split, 0, len(parts))
+	seen := make(map[uuid.UUID]bool, len(parts))
+	var sum int64
+	for _, p := range parts {
+		if p.Amount <= 0 {
+			return ErrNonPositiveAmount
+		}
+		if p.Category.DeletedAt != nil {
+			return ErrCategoryDeleted
+		}
+		if p.Category.Type != typ {
+			return ErrCategoryTypeMismatch
+		}
+		if seen[p.Category.ID] {
+			return ErrDuplicateSplitCategory
+		}
+		seen[p.Category.ID] = true
+		sum += p.Amount
+		splits = append(splits, Split{CategoryID: &p.Category.ID, Amount: p.Amount})
+	}
+	if len(splits) > 0 && sum != o.Amount {
+		return ErrSplitSumMismatch
+	}
+
+	o.Splits = splits
+	return nil
 }
 
 func newOperation(
@@ -33,13 +86,17 @@ func newOperation(
 	typ OperationType,
 	amount int64,
 	description string,
+	at time.Time,
 ) (*Operation, error) {
+	if amount <= 0 {
+		return nil, ErrNonPositiveAmount
+	}
 	return &Operation{
 		ID:          uuid.New(), // Should be set in DB
 		AccountID:   accID,
 		Type:        typ,
 		Amount:      amount,
-		Time:        TimeFunc(),
+		Time:        at,
 		Description: description,
 		CategoryID:  nil,
 	}, nil
@@ -49,6 +106,9 @@ func (o *Operation) apply(acc *BankAccount) error {
 	if o.applied {
 		return ErrAlreadyApplied
 	}
+	if acc.ClosedAt != nil {
+		return ErrAccountClosed
+	}
 	if acc.Blocked {
 		return ErrAccountBlocked
 	}
@@ -57,7 +117,7 @@ func (o *Operation) apply(acc *BankAccount) error {
 	case OperationTypeIncome:
 		acc.Balance += o.Amount
 	case OperationTypeOutcome:
-		if acc.Balance-o.Amount < 0 {
+		if o.Amount > acc.AvailableToSpend() {
 			return ErrNotEnoughMoney
 		}
 		acc.Balance -= o.Amount
@@ -66,21 +126,116 @@ func (o *Operation) apply(acc *BankAccount) error {
 	}
 
 	o.applied = true
+	acc.record(EventOperationApplied, AccountEventData{
+		OperationID:   o.ID,
+		OperationType: o.Type,
+		Amount:        o.Amount,
+		OperationTime: o.Time,
+	})
 	return nil
 }
 
+// BalanceChange is the amount the operation added to the account balance, negative for outcomes.
+func (o *Operation) BalanceChange() int64 {
+	if o.Type == OperationTypeOutcome {
+		return -o.Amount
+	}
+	return o.Amount
+}
+
 func (o *Operation) SetCategory(cat *Category) error {
+	typ, err := ResolveCategoryType(o)
+	if err != nil {
+		return err
+	}
+	if cat.DeletedAt != nil {
+		return ErrCategoryDeleted
+	}
+	if cat.Type != typ {
+		return ErrCategoryTypeMismatch
+	}
 	o.CategoryID = &cat.ID
 	return nil
 }
 
+func (o *Operation) AddTags(tags ...string) error {
+	set := make(map[string]bool, len(o.Tags)+len(tags))
+	for _, t := range o.Tags {
+		set[t] = true
+	}
+	for _, t := range tags {
+		t, err := NormalizeTag(t)
+		if err != nil {
+			return err
+		}
+		set[t] = true
+	}
+
+	o.Tags = make([]string, 0, len(set))
+	for t := range set {
+		o.Tags = append(o.Tags, t)
+	}
+	sort.Strings(o.Tags)
+	return nil
+}
+
+// RemoveTags removes tags, missing ones are ignored.
+func (o *Operation) RemoveTags(tags ...string) error {
+	remove := make(map[string]bool, len(tags))
+	for _, t := range tags {
+		t, err := NormalizeTag(t)
+		if err != nil {
+			return err
+		}
+		remove[t] = true
+	}
+
+	kept := o.Tags[:0]
+	for _, t := range o.Tags {
+		if !remove[t] {
+			kept = append(kept, t)
+		}
+	}
+	o.Tags = kept
+	return nil
+}
+
 func ApplyOperation(
 	acc *BankAccount,
 	typ OperationType,
 	amount int64,
 	description string,
 ) (*Operation, error) {
-	op, err := newOperation(acc.ID, typ, amount, description)
+	return applyOperation(acc, typ, amount, description, TimeFunc())
+}
+
+// ApplyOperationAt applies an operation made at the given time, e.g. entered later from a receipt.
+// The time can't be before the account was created or later than maxFuture from now.
+func ApplyOperationAt(
+	acc *BankAccount,
+	typ OperationType,
+	amount int64,
+	description string,
+	at time.Time,
+	maxFuture time.Duration,
+) (*Operation, error) {
+	if at.Before(acc.CreatedAt) {
+		return nil, ErrOperationBeforeAccount
+	}
+	if at.After(TimeFunc().Add(maxFuture)) {
+		return nil, ErrOperationTooFarInFuture
+	}
+	return applyOperation(acc, typ, amount, description, at)
+}
+
+func applyOperation(
+	acc *BankAccount,
+	typ OperationType,
+	amount int64,
+	description string,
+	at time.Time,
+) (*Operation, error) {
+	op, err := newOperation(acc.ID, typ, amount, description, at)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestApplyOperationRejectsNonPositiveAmount(t *testing.T) {
+	for _, amount := range []int64{0, -100} {
+		acc, err := NewBankAccount("Main", "RUB", AccountTypeDebit, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		acc.Balance = 500
+
+		if _, err := ApplyOperation(acc, OperationTypeOutcome, amount, ""); !errors.Is(err, ErrNonPositiveAmount) {
+			t.Errorf("outcome of %d: err = %v, want ErrNonPositiveAmount", amount, err)
+		}
+		if _, err := ApplyOperationAt(acc, OperationTypeIncome, amount, "", acc.CreatedAt, time.Hour); !errors.Is(err, ErrNonPositiveAmount) {
+			t.Errorf("income of %d: err = %v, want ErrNonPositiveAmount", amount, err)
+		}
+		if acc.Balance != 500 {
+			t.Errorf("balance = %d after rejected operations of %d, want 500", acc.Balance, amount)
+		}
+	}
+}
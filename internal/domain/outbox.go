@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OutboxStatus string
+
+// MaxOutboxBackoff caps the delay between attempts, doubling it forever would overflow.
+const MaxOutboxBackoff = 24 * time.Hour
+
+const (
+	OutboxPending   OutboxStatus = "pending"
+	OutboxDelivered OutboxStatus = "delivered"
+	// OutboxDead messages exhausted their attempts and wait for a manual requeue.
+	OutboxDead OutboxStatus = "dead"
+)
+
+// OutboxMessage is an event to deliver to webhooks. It is saved in the same
+// transaction as the change it describes, so a change is never lost or announced twice.
+type OutboxMessage struct {
+	ID            uuid.UUID
+	Type          string
+	Payload       json.RawMessage
+	CreatedAt     time.Time
+	Status        OutboxStatus
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+}
+
+func NewOutboxMessage(typ string, payload any) (*OutboxMessage, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	now := TimeFunc()
+	return &OutboxMessage{
+		ID:            uuid.New(),
+		Type:          typ,
+		Payload:       raw,
+		CreatedAt:     now,
+		Status:        OutboxPending,
+		NextAttemptAt: now,
+	}, nil
+}
+
+// Claim hides the pending message from other dispatchers for the lease, while it's being sent.
+// If the result isn't recorded by then, e.g. the dispatcher was stopped, the message is due again.
+func (m *OutboxMessage) Claim(lease time.Duration) {
+	m.NextAttemptAt = TimeFunc().Add(lease)
+}
+
+func (m *OutboxMessage) MarkDelivered() {
+	m.Attempts++
+	m.Status = OutboxDelivered
+	m.LastError = ""
+}
+
+// MarkFailed schedules the next attempt with exponential backoff starting at base
+// and capped by MaxOutboxBackoff, or moves the message to the dead state after maxAttempts.
+func (m *OutboxMessage) MarkFailed(err error, base time.Duration, maxAttempts int) {
+	m.Attempts++
+	m.LastError = err.Error()
+	if m.Attempts >= maxAttempts {
+		m.Status = OutboxDead
+		return
+	}
+	delay := base
+	for i := 1; i < m.Attempts && delay < MaxOutboxBackoff; i++ {
+		delay *= 2
+	}
+	m.NextAttemptAt = TimeFunc().Add(min(delay, MaxOutboxBackoff))
+}
+
+// Requeue gives a dead message another round of attempts.
+func (m *OutboxMessage) Requeue() error {
+	if m.Status != OutboxDead {
+		return ErrOutboxNotDead
+	}
+	m.Status = OutboxPending
+	m.Attempts = 0
+	m.NextAttemptAt = TimeFunc()
+	return nil
+}
@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMarkFailedCapsBackoff(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	prev := TimeFunc
+	TimeFunc = func() time.Time { return now }
+	t.Cleanup(func() { TimeFunc = prev })
+
+	msg, err := NewOutboxMessage("operation.applied", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 100 {
+		msg.MarkFailed(errors.New("unavailable"), 30*time.Second, 1000)
+		if delay := msg.NextAttemptAt.Sub(now); delay <= 0 || delay > MaxOutboxBackoff {
+			t.Fatalf("attempt %d: delay = %s, want in (0, %s]", msg.Attempts, delay, MaxOutboxBackoff)
+		}
+	}
+	if delay := msg.NextAttemptAt.Sub(now); delay != MaxOutboxBackoff {
+		t.Errorf("delay after 100 attempts = %s, want %s", delay, MaxOutboxBackoff)
+	}
+}
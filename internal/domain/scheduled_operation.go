@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/teambition/rrule-go"
+)
+
+// ScheduledOperation is an operation template repeated by an RFC 5545 recurrence rule,
+// e.g. "FREQ=MONTHLY;BYMONTHDAY=5" for a salary.
+type ScheduledOperation struct {
+	ID          uuid.UUID
+	AccountID   uuid.UUID
+	Type        OperationType
+	Amount      int64
+	Description string
+	CategoryID  *uuid.UUID
+	Recurrence  string
+	StartAt     time.Time
+}
+
+// ScheduledOccurrence links a materialized occurrence to the operation it produced.
+type ScheduledOccurrence struct {
+	ScheduleID  uuid.UUID
+	OccursAt    time.Time
+	OperationID uuid.UUID
+}
+
+func NewScheduledOperation(
+	accID uuid.UUID,
+	typ OperationType,
+	amount int64,
+	description string,
+	recurrence string,
+	startAt time.Time,
+) (*ScheduledOperation, error) {
+	if typ != OperationTypeIncome && typ != OperationTypeOutcome {
+		return nil, ErrUnknownOperationType
+	}
+	if amount <= 0 {
+		return nil, ErrNonPositiveAmount
+	}
+	s := &ScheduledOperation{
+		ID:          uuid.New(),
+		AccountID:   accID,
+		Type:        typ,
+		Amount:      amount,
+		Description: description,
+		Recurrence:  recurrence,
+		StartAt:     startAt.Truncate(time.Second),
+	}
+	if _, err := s.rule(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *ScheduledOperation) SetCategory(cat *Category) error {
+	typ, err := ResolveCategoryType(&Operation{Type: s.Type})
+	if err != nil {
+		return err
+	}
+	if cat.Type != typ {
+		return ErrCategoryTypeMismatch
+	}
+	s.CategoryID = &cat.ID
+	return nil
+}
+
+// Due returns occurrences after last (or since the start if nothing was materialized yet)
+// up to and including until, in chronological order.
+func (s *ScheduledOperation) Due(last *time.Time, until time.Time) ([]time.Time, error) {
+	rule, err := s.rule()
+	if err != nil {
+		return nil, err
+	}
+	if last == nil {
+		return rule.Between(s.StartAt, until, true), nil
+	}
+
+	occurrences := rule.Between(*last, until, true)
+	if len(occurrences) > 0 && !occurrences[0].After(*last) {
+		occurrences = occurrences[1:]
+	}
+	return occurrences, nil
+}
+
+func (s *ScheduledOperation) rule() (*rrule.RRule, error) {
+	opt, err := rrule.StrToROption(s.Recurrence)
+	if err != nil {
+		return nil, ErrInvalidRecurrence
+	}
+	opt.Dtstart = s.StartAt
+	rule, err := rrule.NewRRule(*opt)
+	if err != nil {
+		return nil, ErrInvalidRecurrence
+	}
+	return rule, nil
+}
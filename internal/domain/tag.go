@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NormalizeTag lowercases and trims a tag, so that "Vacation-2026" and "vacation-2026 " are the same.
+// Tags can't be empty or contain whitespace or commas.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", ErrInvalidTag
+	}
+	if strings.IndexFunc(tag, func(r rune) bool { return unicode.IsSpace(r) || r == ',' }) >= 0 {
+		return "", ErrInvalidTag
+	}
+	return tag, nil
+}
@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User is someone using the CLI with an API token. Only the token hash is stored.
+type User struct {
+	ID        uuid.UUID
+	Name      string
+	TokenHash string
+	CreatedAt time.Time
+}
+
+// NewUser creates a user and its API token, the token can't be recovered later.
+func NewUser(name string) (*User, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", ErrEmptyName
+	}
+	u := &User{
+		ID:        uuid.New(),
+		Name:      name,
+		CreatedAt: TimeFunc(),
+	}
+	token, err := u.RotateToken()
+	if err != nil {
+		return nil, "", err
+	}
+	return u, token, nil
+}
+
+// RotateToken replaces the API token, the previous one stops working.
+func (u *User) RotateToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := "bk_" + hex.EncodeToString(raw)
+	u.TokenHash = HashToken(token)
+	return token, nil
+}
+
+// HashToken is how API tokens are stored and looked up.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Role is what a member may do with an account, each role allows everything of the lower ones.
+type Role string
+
+const (
+	// RoleViewer sees the account and its operations.
+	RoleViewer Role = "viewer"
+	// RoleEditor also applies incomes and outcomes and edits operations.
+	RoleEditor Role = "editor"
+	// RoleOwner also transfers money out, changes, closes and shares the account.
+	RoleOwner Role = "owner"
+)
+
+func ParseRole(s string) (Role, error) {
+	switch r := Role(strings.ToLower(strings.TrimSpace(s))); r {
+	case RoleViewer, RoleEditor, RoleOwner:
+		return r, nil
+	default:
+		return "", ErrUnknownRole
+	}
+}
+
+// Allows reports whether the role includes need.
+func (r Role) Allows(need Role) bool {
+	return r.rank() >= need.rank()
+}
+
+func (r Role) rank() int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleEditor:
+		return 2
+	case RoleOwner:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Membership gives a user a role on an account.
+type Membership struct {
+	AccountID uuid.UUID
+	UserID    uuid.UUID
+	Role      Role
+}
+
+// ChangeMember checks that changing the role of a member to role, or removing it when role is empty,
+// leaves the account with an owner. members are all current members of the account.
+func ChangeMember(members []Membership, userID uuid.UUID, role Role) error {
+	owners := 0
+	for _, m := range members {
+		if m.Role == RoleOwner && m.UserID != userID {
+			owners++
+		}
+	}
+	if owners == 0 && role != RoleOwner {
+		return ErrLastOwner
+	}
+	return nil
+}
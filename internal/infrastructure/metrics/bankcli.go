@@ -0,0 +1,153 @@
+// Package metrics exports bankcli metrics with the Prometheus client.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+// Outcomes of service calls and queries.
+const (
+	OutcomeOK = "ok"
+	// OutcomeRejected is a call refused by the rules, e.g. not enough money or a missing account.
+	OutcomeRejected = "rejected"
+	OutcomeError    = "error"
+)
+
+// Outcome classifies err for the outcome label.
+func Outcome(err error) string {
+	var domainErr *domain.Error
+	switch {
+	case err == nil:
+		return OutcomeOK
+	case errors.As(err, &domainErr),
+		errors.Is(err, storage.ErrNotFound),
+		errors.Is(err, storage.ErrConflict),
+		errors.Is(err, storage.ErrConstraint):
+		return OutcomeRejected
+	default:
+		return OutcomeError
+	}
+}
+
+// Instruments are the bankcli metrics updated while commands run.
+type Instruments struct {
+	calls         *prometheus.CounterVec
+	callDuration  *prometheus.HistogramVec
+	queryDuration *prometheus.HistogramVec
+}
+
+func NewInstruments(reg prometheus.Registerer) *Instruments {
+	i := &Instruments{
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "bankcli_service_calls_total",
+			Help: "Service calls by method and outcome.",
+		}, []string{"method", "outcome"}),
+		callDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "bankcli_service_call_duration_seconds",
+			Help: "Duration of service calls by method and outcome.",
+		}, []string{"method", "outcome"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "bankcli_db_query_duration_seconds",
+			Help: "Duration of repository queries by statement and outcome.",
+		}, []string{"statement", "outcome"}),
+	}
+	reg.MustRegister(i.calls, i.callDuration, i.queryDuration)
+	return i
+}
+
+// ObserveCall gets the service method name like "OperationService.Transfer".
+func (i *Instruments) ObserveCall(method string, elapsed time.Duration, err error) {
+	outcome := Outcome(err)
+	i.calls.WithLabelValues(method, outcome).Inc()
+	i.callDuration.WithLabelValues(method, outcome).Observe(elapsed.Seconds())
+}
+
+func (i *Instruments) ObserveQuery(statement string, elapsed time.Duration, err error) {
+	i.queryDuration.WithLabelValues(statement, Outcome(err)).Observe(elapsed.Seconds())
+}
+
+// RegisterPool exports pgxpool.Pool.Stat() on every scrape.
+func RegisterPool(reg prometheus.Registerer, pool *pgxpool.Pool) {
+	gauge := func(name, help string, value func(*pgxpool.Stat) float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help},
+			func() float64 { return value(pool.Stat()) })
+	}
+	counter := func(name, help string, value func(*pgxpool.Stat) float64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{Name: name, Help: help},
+			func() float64 { return value(pool.Stat()) })
+	}
+	reg.MustRegister(
+		gauge("bankcli_db_pool_acquired_conns", "Connections in use.",
+			func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) }),
+		gauge("bankcli_db_pool_idle_conns", "Idle connections.",
+			func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) }),
+		gauge("bankcli_db_pool_total_conns", "Open connections.",
+			func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) }),
+		gauge("bankcli_db_pool_max_conns", "Maximum size of the pool.",
+			func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) }),
+		counter("bankcli_db_pool_acquires_total", "Connections acquired from the pool.",
+			func(s *pgxpool.Stat) float64 { return float64(s.AcquireCount()) }),
+		counter("bankcli_db_pool_empty_acquires_total", "Acquires that waited for a connection.",
+			func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) }),
+		counter("bankcli_db_pool_acquire_duration_seconds_total", "Time spent acquiring connections.",
+			func(s *pgxpool.Stat) float64 { return s.AcquireDuration().Seconds() }),
+	)
+}
+
+type StatsService interface {
+	Stats(ctx context.Context) (*dto.StatsDTO, error)
+}
+
+// statsTimeout bounds the stats query of a scrape, Collect gets no context of the request.
+const statsTimeout = 10 * time.Second
+
+// businessCollector exports balances and operation counts, queried on every scrape.
+type businessCollector struct {
+	svc        StatsService
+	balance    *prometheus.Desc
+	operations *prometheus.Desc
+}
+
+// RegisterBusiness exports balances and operation counts, queried on every scrape.
+func RegisterBusiness(reg prometheus.Registerer, svc StatsService) {
+	reg.MustRegister(&businessCollector{
+		svc: svc,
+		balance: prometheus.NewDesc("bankcli_balance",
+			"Total balance of open accounts by currency, in minor units.", []string{"currency"}, nil),
+		operations: prometheus.NewDesc("bankcli_operations",
+			"Operations stored by type.", []string{"type"}, nil),
+	})
+}
+
+func (c *businessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.balance
+	ch <- c.operations
+}
+
+// Collect reports a failed query as invalid metrics, so the scrape fails
+// instead of showing no balances.
+func (c *businessCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), statsTimeout)
+	defer cancel()
+
+	stats, err := c.svc.Stats(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.balance, err)
+		ch <- prometheus.NewInvalidMetric(c.operations, err)
+		return
+	}
+	for _, b := range stats.Balances {
+		ch <- prometheus.MustNewConstMetric(c.balance, prometheus.GaugeValue, float64(b.Balance), b.Currency)
+	}
+	for _, o := range stats.Operations {
+		ch <- prometheus.MustNewConstMetric(c.operations, prometheus.GaugeValue, float64(o.Count), o.Type)
+	}
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+type stubStats struct {
+	stats *dto.StatsDTO
+	err   error
+}
+
+func (s stubStats) Stats(context.Context) (*dto.StatsDTO, error) {
+	return s.stats, s.err
+}
+
+func newTenantRegistry() (*prometheus.Registry, prometheus.Registerer) {
+	reg := prometheus.NewRegistry()
+	return reg, prometheus.WrapRegistererWith(prometheus.Labels{"tenant": "smiths"}, reg)
+}
+
+// labels gathers reg and returns label sets of the family samples.
+func labels(t *testing.T, reg prometheus.Gatherer, family string) []map[string]string {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	var sets []map[string]string
+	for _, f := range families {
+		if f.GetName() != family {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			set := make(map[string]string)
+			for _, l := range m.GetLabel() {
+				set[l.GetName()] = l.GetValue()
+			}
+			sets = append(sets, set)
+		}
+	}
+	return sets
+}
+
+func TestObserveCallLabelsMethodOutcomeAndTenant(t *testing.T) {
+	reg, tenantReg := newTenantRegistry()
+	ins := NewInstruments(tenantReg)
+
+	ins.ObserveCall("OperationService.Transfer", time.Millisecond, domain.ErrNotEnoughMoney)
+
+	got := labels(t, reg, "bankcli_service_calls_total")
+	want := map[string]string{"method": "OperationService.Transfer", "outcome": OutcomeRejected, "tenant": "smiths"}
+	if len(got) != 1 || len(got[0]) != len(want) {
+		t.Fatalf("samples = %v, want one with %v", got, want)
+	}
+	for k, v := range want {
+		if got[0][k] != v {
+			t.Errorf("label %s = %q, want %q", k, got[0][k], v)
+		}
+	}
+}
+
+func TestBusinessMetricsFailScrapeOnError(t *testing.T) {
+	reg, tenantReg := newTenantRegistry()
+	RegisterBusiness(tenantReg, stubStats{err: errors.New("db is down")})
+
+	if _, err := reg.Gather(); err == nil {
+		t.Error("Gather succeeded, want the stats error")
+	}
+}
+
+func TestBusinessMetrics(t *testing.T) {
+	reg, tenantReg := newTenantRegistry()
+	RegisterBusiness(tenantReg, stubStats{stats: &dto.StatsDTO{
+		Balances:   []dto.CurrencyBalanceDTO{{Currency: "RUB", Balance: 100}},
+		Operations: []dto.OperationCountDTO{{Type: "income", Count: 2}},
+	}})
+
+	if got := labels(t, reg, "bankcli_balance"); len(got) != 1 || got[0]["currency"] != "RUB" || got[0]["tenant"] != "smiths" {
+		t.Errorf("bankcli_balance samples = %v, want RUB of smiths", got)
+	}
+	if got := labels(t, reg, "bankcli_operations"); len(got) != 1 || got[0]["type"] != "income" {
+		t.Errorf("bankcli_operations samples = %v, want income", got)
+	}
+}
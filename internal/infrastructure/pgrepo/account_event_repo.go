@@ -0,0 +1,134 @@
+package pgrepo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+type AccountEventRepo struct {
+	db *DB
+}
+
+func NewAccountEventRepo(db *DB) *AccountEventRepo {
+	return &AccountEventRepo{db: db}
+}
+
+// accountEventData is the JSON form of domain.AccountEventData stored in the data column.
+type accountEventData struct {
+	Name           string               `json:"name,omitempty"`
+	AccountType    domain.AccountType   `json:"account_type,omitempty"`
+	Currency       string               `json:"currency,omitempty"`
+	Balance        int64                `json:"balance,omitempty"`
+	OverdraftLimit int64                `json:"overdraft_limit,omitempty"`
+	OperationID    *uuid.UUID           `json:"operation_id,omitempty"`
+	OperationType  domain.OperationType `json:"operation_type,omitempty"`
+	Amount         int64                `json:"amount,omitempty"`
+	OperationTime  *time.Time           `json:"operation_time,omitempty"`
+}
+
+// Append adds events to the end of their accounts' streams. The caller must hold
+// the account lock, otherwise concurrent appends fail on the version constraint.
+func (r *AccountEventRepo) Append(ctx context.Context, events ...domain.AccountEvent) error {
+	query := `
+		INSERT INTO account_events (id, account_id, version, type, time, data)
+		VALUES (
+			$1, $2,
+			COALESCE((SELECT max(version) FROM account_events WHERE account_id = $2), 0) + 1,
+			$3, $4, $5
+		)
+	`
+
+	for _, e := range events {
+		data := accountEventData{
+			Name:           e.Data.Name,
+			AccountType:    e.Data.AccountType,
+			Currency:       e.Data.Currency,
+			Balance:        e.Data.Balance,
+			OverdraftLimit: e.Data.OverdraftLimit,
+			OperationType:  e.Data.OperationType,
+			Amount:         e.Data.Amount,
+		}
+		if e.Data.OperationID != uuid.Nil {
+			data.OperationID = &e.Data.OperationID
+		}
+		if !e.Data.OperationTime.IsZero() {
+			data.OperationTime = &e.Data.OperationTime
+		}
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("failed to encode account event: %w", err)
+		}
+
+		if _, err := r.db.Exec(ctx, query, e.ID, e.AccountID, e.Type, e.Time, raw); err != nil {
+			return fmt.Errorf("failed to append account event: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ListByAccount returns events of the account in order, up to and including until if it's set.
+func (r *AccountEventRepo) ListByAccount(ctx context.Context, accountID uuid.UUID, until *time.Time) ([]domain.AccountEvent, error) {
+	query := `
+		SELECT id, account_id, type, time, data
+		FROM account_events
+		WHERE account_id = $1 AND ($2::timestamptz IS NULL OR time <= $2)
+		ORDER BY version
+	`
+
+	rows, err := r.db.Query(ctx, query, accountID, until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list account events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []domain.AccountEvent
+	for rows.Next() {
+		var (
+			event domain.AccountEvent
+			raw   []byte
+		)
+		err := rows.Scan(
+			&event.ID,
+			&event.AccountID,
+			&event.Type,
+			&event.Time,
+			&raw,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan account event: %w", err)
+		}
+
+		var data accountEventData
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return nil, fmt.Errorf("failed to decode account event: %w", err)
+		}
+		event.Data = domain.AccountEventData{
+			Name:           data.Name,
+			AccountType:    data.AccountType,
+			Currency:       data.Currency,
+			Balance:        data.Balance,
+			OverdraftLimit: data.OverdraftLimit,
+			OperationType:  data.OperationType,
+			Amount:         data.Amount,
+		}
+		if data.OperationID != nil {
+			event.Data.OperationID = *data.OperationID
+		}
+		if data.OperationTime != nil {
+			event.Data.OperationTime = *data.OperationTime
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %w", err)
+	}
+
+	return events, nil
+}
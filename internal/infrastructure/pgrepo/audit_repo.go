@@ -0,0 +1,101 @@
+package pgrepo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+type AuditRepo struct {
+	db *DB
+}
+
+func NewAuditRepo(db *DB) *AuditRepo {
+	return &AuditRepo{db: db}
+}
+
+func (r *AuditRepo) Create(ctx context.Context, entry *domain.AuditEntry) error {
+	query := `
+		INSERT INTO audit_log (id, actor, action, entity_type, entity_id, before, after, time)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		entry.ID,
+		entry.Actor,
+		entry.Action,
+		entry.EntityType,
+		entry.EntityID,
+		entry.Before,
+		entry.After,
+		entry.Time,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create audit entry: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AuditRepo) Find(ctx context.Context, filter storage.AuditFilter) ([]domain.AuditEntry, error) {
+	var (
+		conds []string
+		args  []any
+	)
+	where := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.EntityType != "" {
+		where("entity_type = $%d", filter.EntityType)
+	}
+	if filter.EntityID != nil {
+		where("entity_id = $%d", *filter.EntityID)
+	}
+	if filter.Since != nil {
+		where("time >= $%d", *filter.Since)
+	}
+
+	query := `
+		SELECT id, actor, action, entity_type, entity_id, before, after, time
+		FROM audit_log
+	`
+	if len(conds) > 0 {
+		query += "WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY time"
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []domain.AuditEntry
+	for rows.Next() {
+		var entry domain.AuditEntry
+		err := rows.Scan(
+			&entry.ID,
+			&entry.Actor,
+			&entry.Action,
+			&entry.EntityType,
+			&entry.EntityID,
+			&entry.Before,
+			&entry.After,
+			&entry.Time,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %w", err)
+	}
+
+	return entries, nil
+}
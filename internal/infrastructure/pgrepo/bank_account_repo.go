@@ -7,33 +7,52 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
 	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
 )
 
 type BankAccountRepo struct {
-	db *pgxpool.Pool
+	db *DB
 }
 
-func NewBankAccountRepo(db *pgxpool.Pool) *BankAccountRepo {
+func NewBankAccountRepo(db *DB) *BankAccountRepo {
 	return &BankAccountRepo{
 		db: db,
 	}
 }
 func (r *BankAccountRepo) Get(ctx context.Context, id uuid.UUID) (*domain.BankAccount, error) {
 	query := `
-		SELECT id, name, balance, blocked
+		SELECT id, name, type, currency, balance, overdraft_limit, blocked, closed_at, created_at
 		FROM bank_accounts
 		WHERE id = $1
 	`
 
+	return r.get(ctx, query, id)
+}
+
+func (r *BankAccountRepo) GetForUpdate(ctx context.Context, id uuid.UUID) (*domain.BankAccount, error) {
+	query := `
+		SELECT id, name, type, currency, balance, overdraft_limit, blocked, closed_at, created_at
+		FROM bank_accounts
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	return r.get(ctx, query, id)
+}
+
+func (r *BankAccountRepo) get(ctx context.Context, query string, id uuid.UUID) (*domain.BankAccount, error) {
 	var account domain.BankAccount
 	err := r.db.QueryRow(ctx, query, id).Scan(
 		&account.ID,
 		&account.Name,
+		&account.Type,
+		&account.Currency,
 		&account.Balance,
+		&account.OverdraftLimit,
 		&account.Blocked,
+		&account.ClosedAt,
+		&account.CreatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -45,13 +64,14 @@ func (r *BankAccountRepo) Get(ctx context.Context, id uuid.UUID) (*domain.BankAc
 	return &account, nil
 }
 
-func (r *BankAccountRepo) List(ctx context.Context) ([]domain.BankAccount, error) {
+func (r *BankAccountRepo) List(ctx context.Context, includeClosed bool) ([]domain.BankAccount, error) {
 	query := `
-		SELECT id, name, balance, blocked
+		SELECT id, name, type, currency, balance, overdraft_limit, blocked, closed_at, created_at
 		FROM bank_accounts
+		WHERE $1 OR closed_at IS NULL
 	`
 
-	rows, err := r.db.Query(ctx, query)
+	rows, err := r.db.Query(ctx, query, includeClosed)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list bank accounts: %w", err)
 	}
@@ -63,8 +83,13 @@ func (r *BankAccountRepo) List(ctx context.Context) ([]domain.BankAccount, error
 		err := rows.Scan(
 			&account.ID,
 			&account.Name,
+			&account.Type,
+			&account.Currency,
 			&account.Balance,
+			&account.OverdraftLimit,
 			&account.Blocked,
+			&account.ClosedAt,
+			&account.CreatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan bank account: %w", err)
@@ -81,21 +106,30 @@ func (r *BankAccountRepo) List(ctx context.Context) ([]domain.BankAccount, error
 
 func (r *BankAccountRepo) Create(ctx context.Context, account *domain.BankAccount) (*domain.BankAccount, error) {
 	query := `
-		INSERT INTO bank_accounts (id, name, balance, blocked)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, name, balance, blocked
+		INSERT INTO bank_accounts (id, name, type, currency, balance, overdraft_limit, blocked, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, name, type, currency, balance, overdraft_limit, blocked, closed_at, created_at
 	`
 
 	err := r.db.QueryRow(ctx, query,
 		account.ID,
 		account.Name,
+		account.Type,
+		account.Currency,
 		account.Balance,
+		account.OverdraftLimit,
 		account.Blocked,
+		account.CreatedAt,
 	).Scan(
 		&account.ID,
 		&account.Name,
+		&account.Type,
+		&account.Currency,
 		&account.Balance,
+		&account.OverdraftLimit,
 		&account.Blocked,
+		&account.ClosedAt,
+		&account.CreatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bank account: %w", err)
@@ -107,21 +141,30 @@ func (r *BankAccountRepo) Create(ctx context.Context, account *domain.BankAccoun
 func (r *BankAccountRepo) Update(ctx context.Context, account *domain.BankAccount) (*domain.BankAccount, error) {
 	query := `
 		UPDATE bank_accounts
-		SET name = $2, balance = $3, blocked = $4
+		SET name = $2, type = $3, currency = $4, balance = $5, overdraft_limit = $6, blocked = $7, closed_at = $8
 		WHERE id = $1
-		RETURNING id, name, balance, blocked
+		RETURNING id, name, type, currency, balance, overdraft_limit, blocked, closed_at, created_at
 	`
 
 	err := r.db.QueryRow(ctx, query,
 		account.ID,
 		account.Name,
+		account.Type,
+		account.Currency,
 		account.Balance,
+		account.OverdraftLimit,
 		account.Blocked,
+		account.ClosedAt,
 	).Scan(
 		&account.ID,
 		&account.Name,
+		&account.Type,
+		&account.Currency,
 		&account.Balance,
+		&account.OverdraftLimit,
 		&account.Blocked,
+		&account.ClosedAt,
+		&account.CreatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -133,19 +176,26 @@ func (r *BankAccountRepo) Update(ctx context.Context, account *domain.BankAccoun
 	return account, nil
 }
 
+// Delete closes the account, it stays available by Get.
 func (r *BankAccountRepo) Delete(ctx context.Context, id uuid.UUID) (*domain.BankAccount, error) {
 	query := `
-		DELETE FROM bank_accounts
+		UPDATE bank_accounts
+		SET closed_at = now()
 		WHERE id = $1
-		RETURNING id, name, balance, blocked
+		RETURNING id, name, type, currency, balance, overdraft_limit, blocked, closed_at, created_at
 	`
 
 	var account domain.BankAccount
 	err := r.db.QueryRow(ctx, query, id).Scan(
 		&account.ID,
 		&account.Name,
+		&account.Type,
+		&account.Currency,
 		&account.Balance,
+		&account.OverdraftLimit,
 		&account.Blocked,
+		&account.ClosedAt,
+		&account.CreatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
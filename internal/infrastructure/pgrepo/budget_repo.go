@@ -0,0 +1,122 @@
+package pgrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+type BudgetRepo struct {
+	db *DB
+}
+
+func NewBudgetRepo(db *DB) *BudgetRepo {
+	return &BudgetRepo{db: db}
+}
+
+func (r *BudgetRepo) List(ctx context.Context) ([]domain.Budget, error) {
+	query := `
+		SELECT id, category_id, period, limit_amount
+		FROM budgets
+	`
+
+	return r.list(ctx, query)
+}
+
+func (r *BudgetRepo) ListByCategory(ctx context.Context, categoryID uuid.UUID) ([]domain.Budget, error) {
+	query := `
+		SELECT id, category_id, period, limit_amount
+		FROM budgets
+		WHERE category_id = $1
+	`
+
+	return r.list(ctx, query, categoryID)
+}
+
+func (r *BudgetRepo) list(ctx context.Context, query string, args ...any) ([]domain.Budget, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list budgets: %w", err)
+	}
+	defer rows.Close()
+
+	var budgets []domain.Budget
+	for rows.Next() {
+		var budget domain.Budget
+		err := rows.Scan(
+			&budget.ID,
+			&budget.CategoryID,
+			&budget.Period,
+			&budget.Limit,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan budget: %w", err)
+		}
+		budgets = append(budgets, budget)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %w", err)
+	}
+
+	return budgets, nil
+}
+
+func (r *BudgetRepo) Create(ctx context.Context, budget *domain.Budget) (*domain.Budget, error) {
+	query := `
+		INSERT INTO budgets (id, category_id, period, limit_amount)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, category_id, period, limit_amount
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		budget.ID,
+		budget.CategoryID,
+		budget.Period,
+		budget.Limit,
+	).Scan(
+		&budget.ID,
+		&budget.CategoryID,
+		&budget.Period,
+		&budget.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create budget: %w", err)
+	}
+
+	return budget, nil
+}
+
+func (r *BudgetRepo) Update(ctx context.Context, budget *domain.Budget) (*domain.Budget, error) {
+	query := `
+		UPDATE budgets
+		SET category_id = $2, period = $3, limit_amount = $4
+		WHERE id = $1
+		RETURNING id, category_id, period, limit_amount
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		budget.ID,
+		budget.CategoryID,
+		budget.Period,
+		budget.Limit,
+	).Scan(
+		&budget.ID,
+		&budget.CategoryID,
+		&budget.Period,
+		&budget.Limit,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to update budget: %w", err)
+	}
+
+	return budget, nil
+}
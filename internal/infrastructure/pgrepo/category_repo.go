@@ -7,31 +7,47 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
 	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
 )
 
 type CategoryRepo struct {
-	db *pgxpool.Pool
+	db *DB
 }
 
-func NewCategoryRepo(db *pgxpool.Pool) *CategoryRepo {
+func NewCategoryRepo(db *DB) *CategoryRepo {
 	return &CategoryRepo{db: db}
 }
 
 func (r *CategoryRepo) Get(ctx context.Context, id uuid.UUID) (*domain.Category, error) {
 	query := `
-		SELECT id, type, name
+		SELECT id, type, name, parent_id, deleted_at
 		FROM categories
 		WHERE id = $1
 	`
 
+	return r.get(ctx, query, id)
+}
+
+func (r *CategoryRepo) GetForUpdate(ctx context.Context, id uuid.UUID) (*domain.Category, error) {
+	query := `
+		SELECT id, type, name, parent_id, deleted_at
+		FROM categories
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	return r.get(ctx, query, id)
+}
+
+func (r *CategoryRepo) get(ctx context.Context, query string, id uuid.UUID) (*domain.Category, error) {
 	var category domain.Category
 	err := r.db.QueryRow(ctx, query, id).Scan(
 		&category.ID,
 		&category.Type,
 		&category.Name,
+		&category.ParentID,
+		&category.DeletedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -43,13 +59,14 @@ func (r *CategoryRepo) Get(ctx context.Context, id uuid.UUID) (*domain.Category,
 	return &category, nil
 }
 
-func (r *CategoryRepo) List(ctx context.Context) ([]domain.Category, error) {
+func (r *CategoryRepo) List(ctx context.Context, includeDeleted bool) ([]domain.Category, error) {
 	query := `
-		SELECT id, type, name
+		SELECT id, type, name, parent_id, deleted_at
 		FROM categories
+		WHERE $1 OR deleted_at IS NULL
 	`
 
-	rows, err := r.db.Query(ctx, query)
+	rows, err := r.db.Query(ctx, query, includeDeleted)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list categories: %w", err)
 	}
@@ -62,6 +79,8 @@ func (r *CategoryRepo) List(ctx context.Context) ([]domain.Category, error) {
 			&category.ID,
 			&category.Type,
 			&category.Name,
+			&category.ParentID,
+			&category.DeletedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan category: %w", err)
@@ -78,19 +97,22 @@ func (r *CategoryRepo) List(ctx context.Context) ([]domain.Category, error) {
 
 func (r *CategoryRepo) Create(ctx context.Context, category *domain.Category) (*domain.Category, error) {
 	query := `
-		INSERT INTO categories (id, type, name)
-		VALUES ($1, $2, $3)
-		RETURNING id, type, name
+		INSERT INTO categories (id, type, name, parent_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, type, name, parent_id, deleted_at
 	`
 
 	err := r.db.QueryRow(ctx, query,
 		category.ID,
 		category.Type,
 		category.Name,
+		category.ParentID,
 	).Scan(
 		&category.ID,
 		&category.Type,
 		&category.Name,
+		&category.ParentID,
+		&category.DeletedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create category: %w", err)
@@ -102,19 +124,23 @@ func (r *CategoryRepo) Create(ctx context.Context, category *domain.Category) (*
 func (r *CategoryRepo) Update(ctx context.Context, category *domain.Category) (*domain.Category, error) {
 	query := `
 		UPDATE categories
-		SET type = $2, name = $3
+		SET type = $2, name = $3, parent_id = $4, deleted_at = $5
 		WHERE id = $1
-		RETURNING id, type, name
+		RETURNING id, type, name, parent_id, deleted_at
 	`
 
 	err := r.db.QueryRow(ctx, query,
 		category.ID,
 		category.Type,
 		category.Name,
+		category.ParentID,
+		category.DeletedAt,
 	).Scan(
 		&category.ID,
 		&category.Type,
 		&category.Name,
+		&category.ParentID,
+		&category.DeletedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -126,11 +152,13 @@ func (r *CategoryRepo) Update(ctx context.Context, category *domain.Category) (*
 	return category, nil
 }
 
+// Delete marks the category deleted, it stays available by Get.
 func (r *CategoryRepo) Delete(ctx context.Context, id uuid.UUID) (*domain.Category, error) {
 	query := `
-		DELETE FROM categories
+		UPDATE categories
+		SET deleted_at = now()
 		WHERE id = $1
-		RETURNING id, type, name
+		RETURNING id, type, name, parent_id, deleted_at
 	`
 
 	var category domain.Category
@@ -138,6 +166,8 @@ func (r *CategoryRepo) Delete(ctx context.Context, id uuid.UUID) (*domain.Catego
 		&category.ID,
 		&category.Type,
 		&category.Name,
+		&category.ParentID,
+		&category.DeletedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
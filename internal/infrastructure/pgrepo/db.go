@@ -0,0 +1,167 @@
+package pgrepo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type txKey struct{}
+
+// DB runs queries of a single tenant. They run in the transaction started by Transactor if ctx
+// carries one, otherwise each in its own transaction, since the tenant is set per transaction
+// and row-level security hides rows of other tenants. Constraint violations are reported as storage errors.
+type DB struct {
+	pool     *pgxpool.Pool
+	tenant   string
+	observer QueryObserver
+}
+
+// QueryObserver is told how long every query took, e.g. to export metrics.
+type QueryObserver interface {
+	// ObserveQuery gets the statement kind like "select" or "insert".
+	ObserveQuery(statement string, elapsed time.Duration, err error)
+}
+
+// NewDB makes a DB of the tenant, observer may be nil.
+func NewDB(pool *pgxpool.Pool, tenant string, observer QueryObserver) *DB {
+	return &DB{pool: pool, tenant: tenant, observer: observer}
+}
+
+// observe reports the query started at start, call it with defer and a pointer to the returned error.
+func (d *DB) observe(sql string, start time.Time, err *error) {
+	if d.observer == nil {
+		return
+	}
+	statement, _, _ := strings.Cut(strings.TrimSpace(sql), " ")
+	d.observer.ObserveQuery(strings.ToLower(statement), time.Since(start), *err)
+}
+
+func (d *DB) Exec(ctx context.Context, sql string, args ...any) (tag pgconn.CommandTag, err error) {
+	defer d.observe(sql, time.Now(), &err)
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		tag, err := tx.Exec(ctx, sql, args...)
+		return tag, mapError(err)
+	}
+
+	err = d.withinTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		tag, err = tx.Exec(ctx, sql, args...)
+		return err
+	})
+	return tag, mapError(err)
+}
+
+func (d *DB) Query(ctx context.Context, sql string, args ...any) (_ pgx.Rows, err error) {
+	defer d.observe(sql, time.Now(), &err)
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		rows, err := tx.Query(ctx, sql, args...)
+		return rows, mapError(err)
+	}
+
+	tx, err := d.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		tx.Rollback(ctx)
+		return nil, mapError(err)
+	}
+	return &txRows{Rows: rows, ctx: ctx, tx: tx}, nil
+}
+
+// QueryRow runs the query when the row is scanned, so that's when it's observed.
+func (d *DB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return row{Row: tx.QueryRow(ctx, sql, args...), db: d, sql: sql}
+	}
+	return txRow{db: d, ctx: ctx, sql: sql, args: args}
+}
+
+// begin starts a transaction scoped to the tenant.
+func (d *DB) begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := d.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// is_local makes the setting end with the transaction, so pooled connections don't keep it.
+	if _, err := tx.Exec(ctx, "SELECT set_config('bankcli.tenant', $1, true)", d.tenant); err != nil {
+		tx.Rollback(ctx)
+		return nil, fmt.Errorf("failed to set tenant: %w", err)
+	}
+	return tx, nil
+}
+
+func (d *DB) withinTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := d.begin(ctx)
+	if err != nil {
+		return err
+	}
+	// Rollback after Commit does nothing, it's here for failures and panics.
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// txRows ends the transaction of a standalone query when the rows are closed.
+type txRows struct {
+	pgx.Rows
+	ctx  context.Context
+	tx   pgx.Tx
+	done bool
+}
+
+func (r *txRows) Close() {
+	r.Rows.Close()
+	if r.done {
+		return
+	}
+	r.done = true
+	if r.Rows.Err() != nil {
+		r.tx.Rollback(r.ctx)
+		return
+	}
+	r.tx.Commit(r.ctx)
+}
+
+// txRow runs a standalone QueryRow in its own transaction once it's scanned.
+type txRow struct {
+	db   *DB
+	ctx  context.Context
+	sql  string
+	args []any
+}
+
+func (r txRow) Scan(dest ...any) (err error) {
+	defer r.db.observe(r.sql, time.Now(), &err)
+	return mapError(r.db.withinTx(r.ctx, func(tx pgx.Tx) error {
+		return tx.QueryRow(r.ctx, r.sql, r.args...).Scan(dest...)
+	}))
+}
+
+type Transactor struct {
+	db *DB
+}
+
+func NewTransactor(db *DB) *Transactor {
+	return &Transactor{db: db}
+}
+
+// WithinTx runs fn in a transaction of the tenant. Nested calls join the outer transaction.
+func (t *Transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+	return t.db.withinTx(ctx, func(tx pgx.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
@@ -0,0 +1,50 @@
+package pgrepo
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+)
+
+// PostgreSQL error codes of integrity constraint violations.
+const (
+	codeNotNullViolation    = "23502"
+	codeForeignKeyViolation = "23503"
+	codeUniqueViolation     = "23505"
+	codeCheckViolation      = "23514"
+	codeExclusionViolation  = "23P01"
+)
+
+// mapError makes constraint violations match storage.ErrConflict or storage.ErrConstraint,
+// the original error stays in the chain.
+func mapError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case codeUniqueViolation, codeExclusionViolation:
+		return fmt.Errorf("%w: %w", storage.ErrConflict, err)
+	case codeNotNullViolation, codeForeignKeyViolation, codeCheckViolation:
+		return fmt.Errorf("%w: %w", storage.ErrConstraint, err)
+	default:
+		return err
+	}
+}
+
+// row maps the error of Scan, which is where QueryRow reports failures.
+type row struct {
+	pgx.Row
+	db  *DB
+	sql string
+}
+
+func (r row) Scan(dest ...any) (err error) {
+	defer r.db.observe(r.sql, time.Now(), &err)
+	return mapError(r.Row.Scan(dest...))
+}
@@ -0,0 +1,85 @@
+package pgrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+type IdempotencyRepo struct {
+	db *DB
+}
+
+func NewIdempotencyRepo(db *DB) *IdempotencyRepo {
+	return &IdempotencyRepo{db: db}
+}
+
+func (r *IdempotencyRepo) Create(ctx context.Context, key *domain.IdempotencyKey) (bool, error) {
+	// A conflicting uncommitted insert blocks this one until it commits or rolls back.
+	query := `
+		INSERT INTO idempotency_keys (user_id, key, fingerprint, response, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tenant_id, user_id, key) DO NOTHING
+	`
+
+	tag, err := r.db.Exec(ctx, query,
+		key.UserID,
+		key.Key,
+		key.Fingerprint,
+		key.Response,
+		key.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to create idempotency key: %w", err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *IdempotencyRepo) Get(ctx context.Context, userID uuid.UUID, key string) (*domain.IdempotencyKey, error) {
+	query := `
+		SELECT user_id, key, fingerprint, response, created_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND key = $2
+	`
+
+	var k domain.IdempotencyKey
+	err := r.db.QueryRow(ctx, query, userID, key).Scan(
+		&k.UserID,
+		&k.Key,
+		&k.Fingerprint,
+		&k.Response,
+		&k.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	return &k, nil
+}
+
+func (r *IdempotencyRepo) Update(ctx context.Context, key *domain.IdempotencyKey) error {
+	query := `
+		UPDATE idempotency_keys
+		SET response = $3
+		WHERE user_id = $1 AND key = $2
+	`
+
+	tag, err := r.db.Exec(ctx, query, key.UserID, key.Key, key.Response)
+	if err != nil {
+		return fmt.Errorf("failed to update idempotency key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package pgrepo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+)
+
+// ChangesChannel is the NOTIFY channel fed by triggers on operations and bank_accounts.
+const ChangesChannel = "bankcli_changes"
+
+// Listener receives change notifications of a tenant on a connection taken out of the pool,
+// since LISTEN is bound to a session. Notifications carry only ids, changed rows are read
+// again through the tenant's DB, so row-level security decides what the listener sees.
+type Listener struct {
+	db       *DB
+	accounts *BankAccountRepo
+	ops      *OperationRepo
+}
+
+func NewListener(db *DB) *Listener {
+	return &Listener{
+		db:       db,
+		accounts: NewBankAccountRepo(db),
+		ops:      NewOperationRepo(db),
+	}
+}
+
+// changePayload is the JSON built by the notify triggers.
+type changePayload struct {
+	Kind     string    `json:"kind"`
+	TenantID string    `json:"tenant_id"`
+	ID       uuid.UUID `json:"id"`
+}
+
+// Listen calls fn for every change until ctx is done or fn fails.
+func (l *Listener) Listen(ctx context.Context, fn func(storage.Change) error) error {
+	pooled, err := l.db.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	// The session keeps listening, so it must never return to the pool.
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+ChangesChannel); err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to wait for notification: %w", err)
+		}
+
+		var p changePayload
+		if err := json.Unmarshal([]byte(n.Payload), &p); err != nil {
+			return fmt.Errorf("failed to decode notification: %w", err)
+		}
+		// All tenants share the channel. Skipping other tenants only saves a query,
+		// their rows wouldn't be found anyway.
+		if p.TenantID != l.db.tenant {
+			continue
+		}
+
+		change, err := l.read(ctx, p)
+		if errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err := fn(*change); err != nil {
+			return err
+		}
+	}
+}
+
+// read gets the changed row, ErrNotFound means it isn't visible to the tenant or the kind is unknown.
+func (l *Listener) read(ctx context.Context, p changePayload) (*storage.Change, error) {
+	switch storage.ChangeKind(p.Kind) {
+	case storage.ChangeOperation:
+		op, err := l.ops.Get(ctx, p.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &storage.Change{Kind: storage.ChangeOperation, AccountID: op.AccountID, Operation: op}, nil
+	case storage.ChangeAccount:
+		acc, err := l.accounts.Get(ctx, p.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &storage.Change{Kind: storage.ChangeAccount, AccountID: acc.ID, Account: acc}, nil
+	default:
+		return nil, storage.ErrNotFound
+	}
+}
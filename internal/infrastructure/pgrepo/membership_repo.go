@@ -0,0 +1,120 @@
+package pgrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+type MembershipRepo struct {
+	db *DB
+}
+
+func NewMembershipRepo(db *DB) *MembershipRepo {
+	return &MembershipRepo{db: db}
+}
+
+func (r *MembershipRepo) Get(ctx context.Context, accountID, userID uuid.UUID) (*domain.Membership, error) {
+	query := `
+		SELECT account_id, user_id, role
+		FROM account_members
+		WHERE account_id = $1 AND user_id = $2
+	`
+
+	var m domain.Membership
+	err := r.db.QueryRow(ctx, query, accountID, userID).Scan(
+		&m.AccountID,
+		&m.UserID,
+		&m.Role,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get account member: %w", err)
+	}
+
+	return &m, nil
+}
+
+func (r *MembershipRepo) ListByAccount(ctx context.Context, accountID uuid.UUID) ([]domain.Membership, error) {
+	query := `
+		SELECT account_id, user_id, role
+		FROM account_members
+		WHERE account_id = $1
+	`
+
+	return r.list(ctx, query, accountID)
+}
+
+func (r *MembershipRepo) ListByUser(ctx context.Context, userID uuid.UUID) ([]domain.Membership, error) {
+	query := `
+		SELECT account_id, user_id, role
+		FROM account_members
+		WHERE user_id = $1
+	`
+
+	return r.list(ctx, query, userID)
+}
+
+func (r *MembershipRepo) list(ctx context.Context, query string, args ...any) ([]domain.Membership, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list account members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []domain.Membership
+	for rows.Next() {
+		var m domain.Membership
+		err := rows.Scan(
+			&m.AccountID,
+			&m.UserID,
+			&m.Role,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan account member: %w", err)
+		}
+		members = append(members, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %w", err)
+	}
+
+	return members, nil
+}
+
+func (r *MembershipRepo) Save(ctx context.Context, m *domain.Membership) error {
+	query := `
+		INSERT INTO account_members (account_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (account_id, user_id) DO UPDATE SET role = EXCLUDED.role
+	`
+
+	if _, err := r.db.Exec(ctx, query, m.AccountID, m.UserID, m.Role); err != nil {
+		return fmt.Errorf("failed to save account member: %w", err)
+	}
+	return nil
+}
+
+func (r *MembershipRepo) Delete(ctx context.Context, accountID, userID uuid.UUID) error {
+	query := `
+		DELETE FROM account_members
+		WHERE account_id = $1 AND user_id = $2
+	`
+
+	tag, err := r.db.Exec(ctx, query, accountID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete account member: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
@@ -4,29 +4,52 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
 	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
 )
 
 type OperationRepo struct {
-	db *pgxpool.Pool
+	db *DB
 }
 
-func NewOperationRepo(db *pgxpool.Pool) *OperationRepo {
+func NewOperationRepo(db *DB) *OperationRepo {
 	return &OperationRepo{db: db}
 }
 
 func (r *OperationRepo) Get(ctx context.Context, id uuid.UUID) (*domain.Operation, error) {
 	query := `
-		SELECT id, account_id, type, amount, time, description, category_id
+		SELECT id, account_id, type, amount, time, description, category_id,
+			ARRAY(
+				SELECT t.name FROM operation_tags ot JOIN tags t ON t.id = ot.tag_id
+				WHERE ot.operation_id = operations.id ORDER BY t.name
+			)
 		FROM operations
 		WHERE id = $1
 	`
 
+	return r.get(ctx, query, id)
+}
+
+func (r *OperationRepo) GetForUpdate(ctx context.Context, id uuid.UUID) (*domain.Operation, error) {
+	query := `
+		SELECT id, account_id, type, amount, time, description, category_id,
+			ARRAY(
+				SELECT t.name FROM operation_tags ot JOIN tags t ON t.id = ot.tag_id
+				WHERE ot.operation_id = operations.id ORDER BY t.name
+			)
+		FROM operations
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	return r.get(ctx, query, id)
+}
+
+func (r *OperationRepo) get(ctx context.Context, query string, id uuid.UUID) (*domain.Operation, error) {
 	var operation domain.Operation
 	err := r.db.QueryRow(ctx, query, id).Scan(
 		&operation.ID,
@@ -36,6 +59,7 @@ func (r *OperationRepo) Get(ctx context.Context, id uuid.UUID) (*domain.Operatio
 		&operation.Time,
 		&operation.Description,
 		&operation.CategoryID,
+		&operation.Tags,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -44,12 +68,21 @@ func (r *OperationRepo) Get(ctx context.Context, id uuid.UUID) (*domain.Operatio
 		return nil, fmt.Errorf("failed to get operation: %w", err)
 	}
 
+	ops := []domain.Operation{operation}
+	if err := r.loadSplits(ctx, ops); err != nil {
+		return nil, err
+	}
+	operation = ops[0]
 	return &operation, nil
 }
 
 func (r *OperationRepo) List(ctx context.Context) ([]domain.Operation, error) {
 	query := `
-		SELECT id, account_id, type, amount, time, description, category_id
+		SELECT id, account_id, type, amount, time, description, category_id,
+			ARRAY(
+				SELECT t.name FROM operation_tags ot JOIN tags t ON t.id = ot.tag_id
+				WHERE ot.operation_id = operations.id ORDER BY t.name
+			)
 		FROM operations
 	`
 
@@ -70,6 +103,90 @@ func (r *OperationRepo) List(ctx context.Context) ([]domain.Operation, error) {
 			&operation.Time,
 			&operation.Description,
 			&operation.CategoryID,
+			&operation.Tags,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan operation: %w", err)
+		}
+		operations = append(operations, operation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %w", err)
+	}
+
+	if err := r.loadSplits(ctx, operations); err != nil {
+		return nil, err
+	}
+
+	return operations, nil
+}
+
+func (r *OperationRepo) Find(ctx context.Context, filter storage.OperationFilter) ([]domain.Operation, error) {
+	var (
+		conds []string
+		args  []any
+	)
+	where := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.AccountID != nil {
+		where("account_id = $%d", *filter.AccountID)
+	}
+	if filter.Type != "" {
+		where("type = $%d", filter.Type)
+	}
+	if filter.CategoryIDs != nil {
+		where(`(category_id = ANY($%[1]d)
+			OR id IN (SELECT operation_id FROM operation_splits WHERE category_id = ANY($%[1]d)))`, filter.CategoryIDs)
+	}
+	if len(filter.Tags) > 0 {
+		where(`id IN (
+			SELECT ot.operation_id FROM operation_tags ot JOIN tags t ON t.id = ot.tag_id
+			WHERE t.name = ANY($%[1]d)
+			GROUP BY ot.operation_id
+			HAVING count(*) = cardinality($%[1]d::text[])
+		)`, filter.Tags)
+	}
+	if filter.From != nil {
+		where("time >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		where("time < $%d", *filter.To)
+	}
+
+	query := `
+		SELECT id, account_id, type, amount, time, description, category_id,
+			ARRAY(
+				SELECT t.name FROM operation_tags ot JOIN tags t ON t.id = ot.tag_id
+				WHERE ot.operation_id = operations.id ORDER BY t.name
+			)
+		FROM operations
+	`
+	if len(conds) > 0 {
+		query += "WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY time"
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find operations: %w", err)
+	}
+	defer rows.Close()
+
+	var operations []domain.Operation
+	for rows.Next() {
+		var operation domain.Operation
+		err := rows.Scan(
+			&operation.ID,
+			&operation.AccountID,
+			&operation.Type,
+			&operation.Amount,
+			&operation.Time,
+			&operation.Description,
+			&operation.CategoryID,
+			&operation.Tags,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan operation: %w", err)
@@ -81,9 +198,63 @@ func (r *OperationRepo) List(ctx context.Context) ([]domain.Operation, error) {
 		return nil, fmt.Errorf("error after iterating rows: %w", err)
 	}
 
+	if err := r.loadSplits(ctx, operations); err != nil {
+		return nil, err
+	}
+
 	return operations, nil
 }
 
+func (r *OperationRepo) CountByCategory(ctx context.Context, categoryID uuid.UUID) (int64, error) {
+	query := `
+		SELECT count(*)
+		FROM operations
+		WHERE category_id = $1
+			OR id IN (SELECT operation_id FROM operation_splits WHERE category_id = $1)
+	`
+
+	var count int64
+	if err := r.db.QueryRow(ctx, query, categoryID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count operations: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *OperationRepo) ReassignCategory(ctx context.Context, from, to uuid.UUID) (int64, error) {
+	// Counted before moving, an operation having the category directly and in a split counts once.
+	moved, err := r.CountByCategory(ctx, from)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		UPDATE operations
+		SET category_id = $2
+		WHERE category_id = $1
+	`
+
+	if _, err := r.db.Exec(ctx, query, from, to); err != nil {
+		return 0, fmt.Errorf("failed to reassign operations: %w", err)
+	}
+
+	// A split already having the target category absorbs the moved amount.
+	query = `
+		INSERT INTO operation_splits (operation_id, category_id, amount)
+		SELECT operation_id, $2, amount FROM operation_splits WHERE category_id = $1
+		ON CONFLICT (operation_id, category_id) DO UPDATE
+		SET amount = operation_splits.amount + EXCLUDED.amount
+	`
+	if _, err := r.db.Exec(ctx, query, from, to); err != nil {
+		return 0, fmt.Errorf("failed to reassign operation splits: %w", err)
+	}
+	if _, err := r.db.Exec(ctx, `DELETE FROM operation_splits WHERE category_id = $1`, from); err != nil {
+		return 0, fmt.Errorf("failed to reassign operation splits: %w", err)
+	}
+
+	return moved, nil
+}
+
 func (r *OperationRepo) Create(ctx context.Context, operation *domain.Operation) (*domain.Operation, error) {
 	query := `
 		INSERT INTO operations (id, account_id, type, amount, time, description, category_id)
@@ -111,6 +282,12 @@ func (r *OperationRepo) Create(ctx context.Context, operation *domain.Operation)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create operation: %w", err)
 	}
+	if err := r.saveTags(ctx, operation); err != nil {
+		return nil, err
+	}
+	if err := r.saveSplits(ctx, operation); err != nil {
+		return nil, err
+	}
 
 	return operation, nil
 }
@@ -146,15 +323,121 @@ func (r *OperationRepo) Update(ctx context.Context, operation *domain.Operation)
 		}
 		return nil, fmt.Errorf("failed to update operation: %w", err)
 	}
+	if err := r.saveTags(ctx, operation); err != nil {
+		return nil, err
+	}
+	if err := r.saveSplits(ctx, operation); err != nil {
+		return nil, err
+	}
 
 	return operation, nil
 }
 
+// saveTags replaces tags of the operation, creating missing ones.
+// It should be called within a transaction together with the operation write.
+func (r *OperationRepo) saveTags(ctx context.Context, operation *domain.Operation) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM operation_tags WHERE operation_id = $1`, operation.ID)
+	if err != nil {
+		return fmt.Errorf("failed to clear operation tags: %w", err)
+	}
+
+	for _, tag := range operation.Tags {
+		query := `
+			INSERT INTO tags (id, name)
+			VALUES ($1, $2)
+			ON CONFLICT (tenant_id, name) DO NOTHING
+		`
+		if _, err := r.db.Exec(ctx, query, uuid.New(), tag); err != nil {
+			return fmt.Errorf("failed to create tag: %w", err)
+		}
+
+		query = `
+			INSERT INTO operation_tags (operation_id, tag_id)
+			SELECT $1, id FROM tags WHERE name = $2
+		`
+		if _, err := r.db.Exec(ctx, query, operation.ID, tag); err != nil {
+			return fmt.Errorf("failed to tag operation: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// loadSplits fills splits of the operations with one query.
+func (r *OperationRepo) loadSplits(ctx context.Context, operations []domain.Operation) error {
+	if len(operations) == 0 {
+		return nil
+	}
+
+	index := make(map[uuid.UUID]int, len(operations))
+	ids := make([]uuid.UUID, 0, len(operations))
+	for i, op := range operations {
+		index[op.ID] = i
+		ids = append(ids, op.ID)
+	}
+
+	query := `
+		SELECT operation_id, category_id, amount
+		FROM operation_splits
+		WHERE operation_id = ANY($1)
+		ORDER BY amount DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, ids)
+	if err != nil {
+		return fmt.Errorf("failed to list operation splits: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			operationID uuid.UUID
+			split       domain.Split
+		)
+		if err := rows.Scan(&operationID, &split.CategoryID, &split.Amount); err != nil {
+			return fmt.Errorf("failed to scan operation split: %w", err)
+		}
+		op := &operations[index[operationID]]
+		op.Splits = append(op.Splits, split)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error after iterating rows: %w", err)
+	}
+
+	return nil
+}
+
+// saveSplits replaces splits of the operation.
+// It should be called within a transaction together with the operation write.
+func (r *OperationRepo) saveSplits(ctx context.Context, operation *domain.Operation) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM operation_splits WHERE operation_id = $1`, operation.ID)
+	if err != nil {
+		return fmt.Errorf("failed to clear operation splits: %w", err)
+	}
+
+	for _, split := range operation.Splits {
+		query := `
+			INSERT INTO operation_splits (operation_id, category_id, amount)
+			VALUES ($1, $2, $3)
+		`
+		if _, err := r.db.Exec(ctx, query, operation.ID, split.CategoryID, split.Amount); err != nil {
+			return fmt.Errorf("failed to create operation split: %w", err)
+		}
+	}
+
+	return nil
+}
+
 func (r *OperationRepo) Delete(ctx context.Context, id uuid.UUID) (*domain.Operation, error) {
 	query := `
 		DELETE FROM operations
 		WHERE id = $1
-		RETURNING id, account_id, type, amount, time, description, category_id
+		RETURNING id, account_id, type, amount, time, description, category_id,
+			ARRAY(
+				SELECT t.name FROM operation_tags ot JOIN tags t ON t.id = ot.tag_id
+				WHERE ot.operation_id = operations.id ORDER BY t.name
+			)
 	`
 
 	var operation domain.Operation
@@ -166,6 +449,7 @@ func (r *OperationRepo) Delete(ctx context.Context, id uuid.UUID) (*domain.Opera
 		&operation.Time,
 		&operation.Description,
 		&operation.CategoryID,
+		&operation.Tags,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -0,0 +1,141 @@
+package pgrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+type OutboxRepo struct {
+	db *DB
+}
+
+func NewOutboxRepo(db *DB) *OutboxRepo {
+	return &OutboxRepo{db: db}
+}
+
+func (r *OutboxRepo) Create(ctx context.Context, msg *domain.OutboxMessage) error {
+	query := `
+		INSERT INTO outbox (id, type, payload, created_at, status, attempts, next_attempt_at, last_error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.db.Exec(ctx, query,
+		msg.ID,
+		msg.Type,
+		msg.Payload,
+		msg.CreatedAt,
+		msg.Status,
+		msg.Attempts,
+		msg.NextAttemptAt,
+		msg.LastError,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create outbox message: %w", err)
+	}
+
+	return nil
+}
+
+func (r *OutboxRepo) GetForUpdate(ctx context.Context, id uuid.UUID) (*domain.OutboxMessage, error) {
+	query := `
+		SELECT id, type, payload, created_at, status, attempts, next_attempt_at, last_error
+		FROM outbox
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	msgs, err := r.list(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 {
+		return nil, storage.ErrNotFound
+	}
+	return &msgs[0], nil
+}
+
+func (r *OutboxRepo) ClaimDue(ctx context.Context, now time.Time, limit int) ([]domain.OutboxMessage, error) {
+	query := `
+		SELECT id, type, payload, created_at, status, attempts, next_attempt_at, last_error
+		FROM outbox
+		WHERE status = 'pending' AND next_attempt_at <= $1
+		ORDER BY created_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`
+
+	return r.list(ctx, query, now, limit)
+}
+
+func (r *OutboxRepo) ListByStatus(ctx context.Context, status domain.OutboxStatus) ([]domain.OutboxMessage, error) {
+	query := `
+		SELECT id, type, payload, created_at, status, attempts, next_attempt_at, last_error
+		FROM outbox
+		WHERE status = $1
+		ORDER BY created_at
+	`
+
+	return r.list(ctx, query, status)
+}
+
+func (r *OutboxRepo) list(ctx context.Context, query string, args ...any) ([]domain.OutboxMessage, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	var msgs []domain.OutboxMessage
+	for rows.Next() {
+		var msg domain.OutboxMessage
+		err := rows.Scan(
+			&msg.ID,
+			&msg.Type,
+			&msg.Payload,
+			&msg.CreatedAt,
+			&msg.Status,
+			&msg.Attempts,
+			&msg.NextAttemptAt,
+			&msg.LastError,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox message: %w", err)
+		}
+		msgs = append(msgs, msg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %w", err)
+	}
+
+	return msgs, nil
+}
+
+func (r *OutboxRepo) Update(ctx context.Context, msg *domain.OutboxMessage) error {
+	query := `
+		UPDATE outbox
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_error = $5
+		WHERE id = $1
+	`
+
+	tag, err := r.db.Exec(ctx, query,
+		msg.ID,
+		msg.Status,
+		msg.Attempts,
+		msg.NextAttemptAt,
+		msg.LastError,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update outbox message: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,236 @@
+package pgrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+type ScheduleRepo struct {
+	db *DB
+}
+
+func NewScheduleRepo(db *DB) *ScheduleRepo {
+	return &ScheduleRepo{db: db}
+}
+
+func (r *ScheduleRepo) Get(ctx context.Context, id uuid.UUID) (*domain.ScheduledOperation, error) {
+	query := `
+		SELECT id, account_id, type, amount, description, category_id, recurrence, start_at
+		FROM scheduled_operations
+		WHERE id = $1
+	`
+
+	var schedule domain.ScheduledOperation
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&schedule.ID,
+		&schedule.AccountID,
+		&schedule.Type,
+		&schedule.Amount,
+		&schedule.Description,
+		&schedule.CategoryID,
+		&schedule.Recurrence,
+		&schedule.StartAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get scheduled operation: %w", err)
+	}
+
+	return &schedule, nil
+}
+
+func (r *ScheduleRepo) List(ctx context.Context) ([]domain.ScheduledOperation, error) {
+	query := `
+		SELECT id, account_id, type, amount, description, category_id, recurrence, start_at
+		FROM scheduled_operations
+		ORDER BY start_at
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled operations: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []domain.ScheduledOperation
+	for rows.Next() {
+		var schedule domain.ScheduledOperation
+		err := rows.Scan(
+			&schedule.ID,
+			&schedule.AccountID,
+			&schedule.Type,
+			&schedule.Amount,
+			&schedule.Description,
+			&schedule.CategoryID,
+			&schedule.Recurrence,
+			&schedule.StartAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan scheduled operation: %w", err)
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %w", err)
+	}
+
+	return schedules, nil
+}
+
+func (r *ScheduleRepo) Create(ctx context.Context, schedule *domain.ScheduledOperation) (*domain.ScheduledOperation, error) {
+	query := `
+		INSERT INTO scheduled_operations (id, account_id, type, amount, description, category_id, recurrence, start_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, account_id, type, amount, description, category_id, recurrence, start_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		schedule.ID,
+		schedule.AccountID,
+		schedule.Type,
+		schedule.Amount,
+		schedule.Description,
+		schedule.CategoryID,
+		schedule.Recurrence,
+		schedule.StartAt,
+	).Scan(
+		&schedule.ID,
+		&schedule.AccountID,
+		&schedule.Type,
+		&schedule.Amount,
+		&schedule.Description,
+		&schedule.CategoryID,
+		&schedule.Recurrence,
+		&schedule.StartAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scheduled operation: %w", err)
+	}
+
+	return schedule, nil
+}
+
+func (r *ScheduleRepo) Delete(ctx context.Context, id uuid.UUID) (*domain.ScheduledOperation, error) {
+	query := `
+		DELETE FROM scheduled_operations
+		WHERE id = $1
+		RETURNING id, account_id, type, amount, description, category_id, recurrence, start_at
+	`
+
+	var schedule domain.ScheduledOperation
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&schedule.ID,
+		&schedule.AccountID,
+		&schedule.Type,
+		&schedule.Amount,
+		&schedule.Description,
+		&schedule.CategoryID,
+		&schedule.Recurrence,
+		&schedule.StartAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to delete scheduled operation: %w", err)
+	}
+
+	return &schedule, nil
+}
+
+func (r *ScheduleRepo) ReassignCategory(ctx context.Context, from uuid.UUID, to *uuid.UUID) (int64, error) {
+	query := `
+		UPDATE scheduled_operations
+		SET category_id = $2
+		WHERE category_id = $1
+	`
+
+	tag, err := r.db.Exec(ctx, query, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reassign scheduled operations: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func (r *ScheduleRepo) LastOccurrence(ctx context.Context, scheduleID uuid.UUID) (*time.Time, error) {
+	query := `
+		SELECT max(occurs_at)
+		FROM scheduled_occurrences
+		WHERE schedule_id = $1
+	`
+
+	var last *time.Time
+	if err := r.db.QueryRow(ctx, query, scheduleID).Scan(&last); err != nil {
+		return nil, fmt.Errorf("failed to get last occurrence: %w", err)
+	}
+
+	return last, nil
+}
+
+func (r *ScheduleRepo) ListOccurrences(ctx context.Context, scheduleID uuid.UUID) ([]domain.ScheduledOccurrence, error) {
+	query := `
+		SELECT schedule_id, occurs_at, operation_id
+		FROM scheduled_occurrences
+		WHERE schedule_id = $1
+		ORDER BY occurs_at
+	`
+
+	rows, err := r.db.Query(ctx, query, scheduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list occurrences: %w", err)
+	}
+	defer rows.Close()
+
+	var occurrences []domain.ScheduledOccurrence
+	for rows.Next() {
+		var occurrence domain.ScheduledOccurrence
+		err := rows.Scan(
+			&occurrence.ScheduleID,
+			&occurrence.OccursAt,
+			&occurrence.OperationID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan occurrence: %w", err)
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %w", err)
+	}
+
+	return occurrences, nil
+}
+
+func (r *ScheduleRepo) CreateOccurrence(ctx context.Context, occurrence *domain.ScheduledOccurrence) error {
+	query := `
+		INSERT INTO scheduled_occurrences (schedule_id, occurs_at, operation_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`
+
+	tag, err := r.db.Exec(ctx, query,
+		occurrence.ScheduleID,
+		occurrence.OccursAt,
+		occurrence.OperationID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create occurrence: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.ErrConflict
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package pgrepo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+type StatsRepo struct {
+	db *DB
+}
+
+func NewStatsRepo(db *DB) *StatsRepo {
+	return &StatsRepo{db: db}
+}
+
+func (r *StatsRepo) BalancesByCurrency(ctx context.Context) (map[string]int64, error) {
+	query := `
+		SELECT currency, COALESCE(sum(balance), 0)
+		FROM bank_accounts
+		WHERE closed_at IS NULL
+		GROUP BY currency
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum balances: %w", err)
+	}
+	defer rows.Close()
+
+	balances := make(map[string]int64)
+	for rows.Next() {
+		var (
+			currency string
+			balance  int64
+		)
+		if err := rows.Scan(&currency, &balance); err != nil {
+			return nil, fmt.Errorf("failed to scan balance: %w", err)
+		}
+		balances[currency] = balance
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %w", err)
+	}
+
+	return balances, nil
+}
+
+func (r *StatsRepo) OperationsByType(ctx context.Context) (map[domain.OperationType]int64, error) {
+	query := `
+		SELECT type, count(*)
+		FROM operations
+		GROUP BY type
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count operations: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[domain.OperationType]int64)
+	for rows.Next() {
+		var (
+			typ   domain.OperationType
+			count int64
+		)
+		if err := rows.Scan(&typ, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan operation count: %w", err)
+		}
+		counts[typ] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %w", err)
+	}
+
+	return counts, nil
+}
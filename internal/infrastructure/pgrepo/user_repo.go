@@ -0,0 +1,145 @@
+package pgrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/storage"
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+type UserRepo struct {
+	db *DB
+}
+
+func NewUserRepo(db *DB) *UserRepo {
+	return &UserRepo{db: db}
+}
+
+func (r *UserRepo) Create(ctx context.Context, user *domain.User) (*domain.User, error) {
+	query := `
+		INSERT INTO users (id, name, token_hash, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, name, token_hash, created_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		user.ID,
+		user.Name,
+		user.TokenHash,
+		user.CreatedAt,
+	).Scan(
+		&user.ID,
+		&user.Name,
+		&user.TokenHash,
+		&user.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return user, nil
+}
+
+func (r *UserRepo) GetByName(ctx context.Context, name string) (*domain.User, error) {
+	query := `
+		SELECT id, name, token_hash, created_at
+		FROM users
+		WHERE name = $1
+	`
+
+	return r.get(ctx, query, name)
+}
+
+func (r *UserRepo) GetByTokenHash(ctx context.Context, hash string) (*domain.User, error) {
+	query := `
+		SELECT id, name, token_hash, created_at
+		FROM users
+		WHERE token_hash = $1
+	`
+
+	return r.get(ctx, query, hash)
+}
+
+func (r *UserRepo) get(ctx context.Context, query string, arg any) (*domain.User, error) {
+	var user domain.User
+	err := r.db.QueryRow(ctx, query, arg).Scan(
+		&user.ID,
+		&user.Name,
+		&user.TokenHash,
+		&user.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &user, nil
+}
+
+func (r *UserRepo) List(ctx context.Context) ([]domain.User, error) {
+	query := `
+		SELECT id, name, token_hash, created_at
+		FROM users
+		ORDER BY name
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []domain.User
+	for rows.Next() {
+		var user domain.User
+		err := rows.Scan(
+			&user.ID,
+			&user.Name,
+			&user.TokenHash,
+			&user.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %w", err)
+	}
+
+	return users, nil
+}
+
+func (r *UserRepo) Update(ctx context.Context, user *domain.User) (*domain.User, error) {
+	query := `
+		UPDATE users
+		SET name = $2, token_hash = $3
+		WHERE id = $1
+		RETURNING id, name, token_hash, created_at
+	`
+
+	err := r.db.QueryRow(ctx, query,
+		user.ID,
+		user.Name,
+		user.TokenHash,
+	).Scan(
+		&user.ID,
+		&user.Name,
+		&user.TokenHash,
+		&user.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return user, nil
+}
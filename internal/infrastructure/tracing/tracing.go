@@ -0,0 +1,66 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const ServiceName = "bankcli"
+
+// Exporters accepted by NewExporter.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	// ExporterOTLP sends spans over OTLP/HTTP, the endpoint and headers are read from
+	// the standard OTEL_EXPORTER_OTLP_* variables, http://localhost:4318 by default.
+	ExporterOTLP = "otlp"
+)
+
+var Exporters = []string{ExporterNone, ExporterStdout, ExporterOTLP}
+
+// NewExporter makes the exporter of the kind, stdout spans are written to w.
+// It returns nil for ExporterNone.
+func NewExporter(ctx context.Context, kind string, w io.Writer) (sdktrace.SpanExporter, error) {
+	switch kind {
+	case ExporterNone:
+		return nil, nil
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(w), stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		return otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q, expected one of %v", kind, Exporters)
+	}
+}
+
+// NewProvider batches spans of the tenant to exp. Any exporter fits, e.g. tracetest.NewInMemoryExporter
+// to check spans, which are exported on ForceFlush or Shutdown.
+func NewProvider(exp sdktrace.SpanExporter, tenant string) *sdktrace.TracerProvider {
+	res := resource.NewSchemaless(
+		semconv.ServiceName(ServiceName),
+		attribute.String("bankcli.tenant", tenant),
+	)
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+	)
+}
+
+// End records err on the span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
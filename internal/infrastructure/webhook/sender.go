@@ -0,0 +1,99 @@
+// Package webhook delivers outbox messages over HTTP.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+const (
+	HeaderID        = "X-Bankcli-Id"
+	HeaderType      = "X-Bankcli-Type"
+	HeaderTimestamp = "X-Bankcli-Timestamp"
+	// HeaderSignature is "sha256=" and hex of HMAC-SHA256 over "<timestamp>.<body>" with the shared secret.
+	HeaderSignature = "X-Bankcli-Signature"
+)
+
+var (
+	ErrNoURLs = errors.New("no webhook URLs configured")
+	// ErrNoSecret refuses sending, signatures made with an empty key could be forged by anyone.
+	ErrNoSecret = errors.New("no webhook secret configured")
+)
+
+type Sender struct {
+	urls   []string
+	secret []byte
+	client *http.Client
+}
+
+func NewSender(urls []string, secret string, timeout time.Duration) *Sender {
+	return &Sender{
+		urls:   urls,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Send posts the message payload to every URL and fails if any of them doesn't answer with 2xx.
+func (s *Sender) Send(ctx context.Context, msg *domain.OutboxMessage) error {
+	if len(s.urls) == 0 {
+		return ErrNoURLs
+	}
+	if len(s.secret) == 0 {
+		return ErrNoSecret
+	}
+
+	var errs []error
+	for _, url := range s.urls {
+		if err := s.post(ctx, url, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", url, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Sender) post(ctx context.Context, url string, msg *domain.OutboxMessage) error {
+	timestamp := strconv.FormatInt(domain.TimeFunc().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(msg.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, msg.ID.String())
+	req.Header.Set(HeaderType, msg.Type)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(s.secret, timestamp, msg.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>", receivers use it to verify messages.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sunnyyssh/designing-software-cw1/internal/domain"
+)
+
+func newMessage(t *testing.T) *domain.OutboxMessage {
+	t.Helper()
+	msg, err := domain.NewOutboxMessage("operation.applied", map[string]any{"amount": 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestSendSignsPayload(t *testing.T) {
+	const secret = "s3cret"
+	msg := newMessage(t)
+
+	var got *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	if err := NewSender([]string{srv.URL}, secret, time.Second).Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if string(body) != string(msg.Payload) {
+		t.Errorf("body = %s, want %s", body, msg.Payload)
+	}
+	if id := got.Header.Get(HeaderID); id != msg.ID.String() {
+		t.Errorf("%s = %q, want %q", HeaderID, id, msg.ID)
+	}
+	if typ := got.Header.Get(HeaderType); typ != msg.Type {
+		t.Errorf("%s = %q, want %q", HeaderType, typ, msg.Type)
+	}
+	timestamp := got.Header.Get(HeaderTimestamp)
+	want := "sha256=" + Sign([]byte(secret), timestamp, body)
+	if sig := got.Header.Get(HeaderSignature); sig != want {
+		t.Errorf("%s = %q, want %q", HeaderSignature, sig, want)
+	}
+	if sig := Sign([]byte("other"), timestamp, body); "sha256="+sig == got.Header.Get(HeaderSignature) {
+		t.Error("signature doesn't depend on the secret")
+	}
+}
+
+func TestSendFailsOnServerError(t *testing.T) {
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ok.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	err := NewSender([]string{ok.URL, failing.URL}, "s", time.Second).Send(context.Background(), newMessage(t))
+	if err == nil {
+		t.Fatal("Send succeeded, want an error for the 503 receiver")
+	}
+	if !strings.Contains(err.Error(), failing.URL) || strings.Contains(err.Error(), ok.URL) {
+		t.Errorf("error %q should name only the failing receiver", err)
+	}
+}
+
+func TestSendWithoutURLs(t *testing.T) {
+	if err := NewSender(nil, "s", time.Second).Send(context.Background(), newMessage(t)); err != ErrNoURLs {
+		t.Errorf("Send = %v, want ErrNoURLs", err)
+	}
+}
+
+func TestSendWithoutSecret(t *testing.T) {
+	var called bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	defer srv.Close()
+
+	if err := NewSender([]string{srv.URL}, "", time.Second).Send(context.Background(), newMessage(t)); err != ErrNoSecret {
+		t.Errorf("Send = %v, want ErrNoSecret", err)
+	}
+	if called {
+		t.Error("receiver was called without a secret")
+	}
+}
@@ -0,0 +1,312 @@
+package settings
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	EnvConfigPath      = "BANKCLI_CONFIG"
+	EnvProfile         = "BANKCLI_PROFILE"
+	EnvConnString      = "PG_CONN_STRING"
+	EnvDefaultAccount  = "BANKCLI_DEFAULT_ACCOUNT"
+	EnvDefaultCurrency = "BANKCLI_DEFAULT_CURRENCY"
+	EnvOutput          = "BANKCLI_OUTPUT"
+	EnvActor           = "BANKCLI_ACTOR"
+	EnvWebhookURLs     = "BANKCLI_WEBHOOK_URLS"
+	EnvWebhookSecret   = "BANKCLI_WEBHOOK_SECRET"
+	EnvMaxFuture       = "BANKCLI_MAX_FUTURE"
+	EnvAPIToken        = "BANKCLI_API_TOKEN"
+	EnvTenant          = "BANKCLI_TENANT"
+	EnvTraceExporter   = "BANKCLI_TRACE_EXPORTER"
+)
+
+const (
+	DefaultProfile  = "default"
+	DefaultCurrency = "RUB"
+	DefaultOutput   = "json"
+	// DefaultTenant owns data created before tenants existed.
+	DefaultTenant = "default"
+	// DefaultMaxFuture allows entering an operation a day ahead, e.g. from another time zone.
+	DefaultMaxFuture = "24h"
+	// DefaultTraceExporter disables tracing.
+	DefaultTraceExporter = "none"
+)
+
+var ErrUnknownKey = errors.New("unknown settings key")
+
+// Profile is a named set of settings stored in the config file.
+type Profile struct {
+	ConnString      string `yaml:"conn_string,omitempty" json:"conn_string,omitempty"`
+	DefaultAccount  string `yaml:"default_account,omitempty" json:"default_account,omitempty"`
+	DefaultCurrency string `yaml:"default_currency,omitempty" json:"default_currency,omitempty"`
+	Output          string `yaml:"output,omitempty" json:"output,omitempty"`
+	// Actor is the name written to the audit log, the OS user by default.
+	Actor string `yaml:"actor,omitempty" json:"actor,omitempty"`
+	// WebhookURLs is a comma separated list of URLs the outbox is delivered to.
+	WebhookURLs   string `yaml:"webhook_urls,omitempty" json:"webhook_urls,omitempty"`
+	WebhookSecret string `yaml:"webhook_secret,omitempty" json:"webhook_secret,omitempty"`
+	// MaxFuture is a Go duration, how far ahead of now an operation time may be set.
+	MaxFuture string `yaml:"max_future,omitempty" json:"max_future,omitempty"`
+	// APIToken authenticates the user, see `bankcli user create`.
+	APIToken string `yaml:"api_token,omitempty" json:"api_token,omitempty"`
+	// Tenant is the household whose data is visible, others are hidden by row-level security.
+	Tenant string `yaml:"tenant,omitempty" json:"tenant,omitempty"`
+	// TraceExporter is where spans are sent: none, stdout or otlp, see OTEL_EXPORTER_OTLP_ENDPOINT.
+	TraceExporter string `yaml:"trace_exporter,omitempty" json:"trace_exporter,omitempty"`
+}
+
+// Keys lists the profile keys accepted by Set.
+var Keys = []string{"conn_string", "default_account", "default_currency", "output", "actor", "webhook_urls", "webhook_secret", "max_future", "api_token", "tenant", "trace_exporter"}
+
+func (p *Profile) field(key string) (*string, error) {
+	switch key {
+	case "conn_string":
+		return &p.ConnString, nil
+	case "default_account":
+		return &p.DefaultAccount, nil
+	case "default_currency":
+		return &p.DefaultCurrency, nil
+	case "output":
+		return &p.Output, nil
+	case "actor":
+		return &p.Actor, nil
+	case "webhook_urls":
+		return &p.WebhookURLs, nil
+	case "webhook_secret":
+		return &p.WebhookSecret, nil
+	case "max_future":
+		return &p.MaxFuture, nil
+	case "api_token":
+		return &p.APIToken, nil
+	case "tenant":
+		return &p.Tenant, nil
+	case "trace_exporter":
+		return &p.TraceExporter, nil
+	default:
+		return nil, fmt.Errorf("%w %q, expected one of %v", ErrUnknownKey, key, Keys)
+	}
+}
+
+// Redacted returns a copy of the profile with the password hidden in the connection string
+// and the webhook secret and API token hidden.
+func (p Profile) Redacted() Profile {
+	if p.WebhookSecret != "" {
+		p.WebhookSecret = "xxxxx"
+	}
+	if p.APIToken != "" {
+		p.APIToken = "xxxxx"
+	}
+	u, err := url.Parse(p.ConnString)
+	if err != nil || u.User == nil {
+		return p
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+		p.ConnString = u.String()
+	}
+	return p
+}
+
+// File is the content of the config file.
+type File struct {
+	CurrentProfile string              `yaml:"current_profile,omitempty"`
+	Profiles       map[string]*Profile `yaml:"profiles,omitempty"`
+}
+
+// DefaultPath returns the config file location: $BANKCLI_CONFIG or ~/.config/bankcli/config.yaml.
+func DefaultPath() (string, error) {
+	if path := os.Getenv(EnvConfigPath); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "bankcli", "config.yaml"), nil
+}
+
+// Load reads the config file. A missing file is not an error, it is just empty.
+func Load(path string) (*File, error) {
+	f := &File{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	return f, nil
+}
+
+func (f *File) Save(path string) error {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(f); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	// The file may contain a connection string with a password.
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+// ProfileNames returns sorted names of all profiles in the file.
+func (f *File) ProfileNames() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Set assigns a profile key, creating the profile if it doesn't exist yet.
+func (f *File) Set(profile, key, value string) error {
+	if f.Profiles == nil {
+		f.Profiles = make(map[string]*Profile)
+	}
+	p, ok := f.Profiles[profile]
+	if !ok {
+		p = &Profile{}
+	}
+
+	field, err := p.field(key)
+	if err != nil {
+		return err
+	}
+	*field = value
+
+	f.Profiles[profile] = p
+	return nil
+}
+
+func (f *File) UseProfile(name string) error {
+	if _, ok := f.Profiles[name]; !ok {
+		return fmt.Errorf("profile %q does not exist", name)
+	}
+	f.CurrentProfile = name
+	return nil
+}
+
+// Settings are the effective values after applying precedence rules.
+type Settings struct {
+	Path        string `json:"path"`
+	ProfileName string `json:"profile"`
+	Profile
+
+	defined bool
+}
+
+// Resolve computes effective settings. Precedence is flag > env > file > defaults.
+// flagProfile and flagTenant are values of --profile and --tenant and may be empty.
+func Resolve(path string, file *File, flagProfile, flagTenant string) *Settings {
+	name := firstNonEmpty(flagProfile, os.Getenv(EnvProfile), file.CurrentProfile, DefaultProfile)
+
+	p, defined := file.Profiles[name]
+	if !defined {
+		p = &Profile{}
+	}
+
+	return &Settings{
+		Path:        path,
+		ProfileName: name,
+		Profile: Profile{
+			ConnString:      firstNonEmpty(os.Getenv(EnvConnString), p.ConnString),
+			DefaultAccount:  firstNonEmpty(os.Getenv(EnvDefaultAccount), p.DefaultAccount),
+			DefaultCurrency: firstNonEmpty(os.Getenv(EnvDefaultCurrency), p.DefaultCurrency, DefaultCurrency),
+			Output:          firstNonEmpty(os.Getenv(EnvOutput), p.Output, DefaultOutput),
+			Actor:           firstNonEmpty(os.Getenv(EnvActor), p.Actor, osUser()),
+			WebhookURLs:     firstNonEmpty(os.Getenv(EnvWebhookURLs), p.WebhookURLs),
+			WebhookSecret:   firstNonEmpty(os.Getenv(EnvWebhookSecret), p.WebhookSecret),
+			MaxFuture:       firstNonEmpty(os.Getenv(EnvMaxFuture), p.MaxFuture, DefaultMaxFuture),
+			APIToken:        firstNonEmpty(os.Getenv(EnvAPIToken), p.APIToken),
+			Tenant:          firstNonEmpty(flagTenant, os.Getenv(EnvTenant), p.Tenant, DefaultTenant),
+			TraceExporter:   firstNonEmpty(os.Getenv(EnvTraceExporter), p.TraceExporter, DefaultTraceExporter),
+		},
+		defined: defined || name == DefaultProfile,
+	}
+}
+
+// Validate checks that settings are enough to connect the database.
+func (s *Settings) Validate() error {
+	if !s.defined {
+		return fmt.Errorf("profile %q is not defined in %s", s.ProfileName, s.Path)
+	}
+	if s.ConnString == "" {
+		return fmt.Errorf("connection string is not set: export %s or run `bankcli config set conn_string <value>`", EnvConnString)
+	}
+	if !validTenant(s.Tenant) {
+		return fmt.Errorf("tenant must be 1-64 letters, digits, '-' or '_', got %q", s.Tenant)
+	}
+	if d, err := time.ParseDuration(s.MaxFuture); err != nil || d < 0 {
+		return fmt.Errorf("max_future must be a non-negative duration like 24h, got %q", s.MaxFuture)
+	}
+	return nil
+}
+
+func validTenant(tenant string) bool {
+	if len(tenant) == 0 || len(tenant) > 64 {
+		return false
+	}
+	for _, r := range tenant {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func osUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return "unknown"
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// MaxFutureDuration parses MaxFuture, which Validate has checked.
+func (p Profile) MaxFutureDuration() time.Duration {
+	d, _ := time.ParseDuration(p.MaxFuture)
+	return d
+}
+
+// WebhookURLList splits WebhookURLs, skipping empty entries.
+func (p Profile) WebhookURLList() []string {
+	var urls []string
+	for _, u := range strings.Split(p.WebhookURLs, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
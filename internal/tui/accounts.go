@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"strconv"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+)
+
+const accountsHelp = "[yellow]Enter[-] operations  [yellow]i[-] income  [yellow]o[-] outcome  [yellow]t[-] transfer  [yellow]r[-] refresh  [yellow]q[-] quit"
+
+type accountsView struct {
+	app *App
+
+	root     *tview.Flex
+	table    *tview.Table
+	accounts []dto.BankAccountDTO
+}
+
+func newAccountsView(app *App) *accountsView {
+	v := &accountsView{
+		app:   app,
+		table: tview.NewTable().SetSelectable(true, false).SetFixed(1, 0),
+	}
+	v.table.SetBorder(true).SetTitle(" Accounts ")
+	v.table.SetSelectedFunc(func(row, _ int) {
+		if acc, ok := v.accountAt(row); ok {
+			app.showOperations(acc)
+		}
+	})
+	v.table.SetInputCapture(v.handleKeys)
+
+	v.root = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(v.table, 0, 1, true).
+		AddItem(tview.NewTextView().SetDynamicColors(true).SetText(accountsHelp), 1, 0, false)
+	return v
+}
+
+func (v *accountsView) setAccounts(accounts []dto.BankAccountDTO) {
+	v.accounts = accounts
+
+	row, _ := v.table.GetSelection()
+	v.table.Clear()
+	for col, title := range []string{"ID", "Name", "Balance", "Available", "Status"} {
+		v.table.SetCell(0, col, tview.NewTableCell(title).
+			SetTextColor(tcell.ColorYellow).
+			SetSelectable(false))
+	}
+	for i, acc := range accounts {
+		status := "active"
+		if acc.Blocked {
+			status = "blocked"
+		}
+		v.table.SetCell(i+1, 0, tview.NewTableCell(shortID(acc.ID)))
+		v.table.SetCell(i+1, 1, tview.NewTableCell(tview.Escape(acc.Name)).SetExpansion(1))
+		v.table.SetCell(i+1, 2, tview.NewTableCell(strconv.FormatInt(acc.Balance, 10)).SetAlign(tview.AlignRight))
+		v.table.SetCell(i+1, 3, tview.NewTableCell(strconv.FormatInt(acc.AvailableToSpend, 10)).SetAlign(tview.AlignRight))
+		v.table.SetCell(i+1, 4, tview.NewTableCell(status))
+	}
+
+	if row < 1 {
+		row = 1
+	}
+	if row > len(accounts) {
+		row = len(accounts)
+	}
+	v.table.Select(row, 0)
+}
+
+func (v *accountsView) accountAt(row int) (dto.BankAccountDTO, bool) {
+	if row < 1 || row > len(v.accounts) {
+		return dto.BankAccountDTO{}, false
+	}
+	return v.accounts[row-1], true
+}
+
+func (v *accountsView) selected() (dto.BankAccountDTO, bool) {
+	row, _ := v.table.GetSelection()
+	return v.accountAt(row)
+}
+
+func (v *accountsView) handleKeys(event *tcell.EventKey) *tcell.EventKey {
+	acc, ok := v.selected()
+
+	switch event.Rune() {
+	case 'r':
+		v.app.reload(v.app.ctx)
+	case 'i':
+		if ok {
+			v.app.showOperationForm(acc, "income")
+		}
+	case 'o':
+		if ok {
+			v.app.showOperationForm(acc, "outcome")
+		}
+	case 't':
+		if ok {
+			v.app.showTransferForm(acc)
+		}
+	default:
+		return event
+	}
+	return nil
+}
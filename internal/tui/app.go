@@ -0,0 +1,189 @@
+package tui
+
+import (
+	"context"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/google/uuid"
+	"github.com/rivo/tview"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/services"
+)
+
+type BankAccountService interface {
+	List(ctx context.Context, includeClosed bool) ([]dto.BankAccountDTO, error)
+}
+
+type OperationService interface {
+	List(ctx context.Context) ([]dto.OperationDTO, error)
+	ApplyOperation(ctx context.Context, req services.ApplyOperationRequest) (*services.ApplyOperationResponse, error)
+	Transfer(ctx context.Context, req services.TransferRequest) (*services.TransferResponse, error)
+}
+
+const (
+	pageAccounts   = "accounts"
+	pageOperations = "operations"
+	pageForm       = "form"
+)
+
+// App is a full-screen terminal UI over the bank account and operation services.
+type App struct {
+	accSvc BankAccountService
+	opSvc  OperationService
+
+	refreshInterval time.Duration
+
+	ctx       context.Context
+	app       *tview.Application
+	pages     *tview.Pages
+	status    *tview.TextView
+	accounts  *accountsView
+	ops       *operationsView
+	formShown bool
+}
+
+func New(accSvc BankAccountService, opSvc OperationService, refreshInterval time.Duration) *App {
+	a := &App{
+		accSvc:          accSvc,
+		opSvc:           opSvc,
+		refreshInterval: refreshInterval,
+		app:             tview.NewApplication(),
+		pages:           tview.NewPages(),
+		status:          tview.NewTextView().SetDynamicColors(true),
+	}
+	a.accounts = newAccountsView(a)
+	a.ops = newOperationsView(a)
+
+	a.pages.
+		AddPage(pageAccounts, a.accounts.root, true, true).
+		AddPage(pageOperations, a.ops.root, true, false)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(a.pages, 0, 1, true).
+		AddItem(a.status, 1, 0, false)
+
+	a.app.SetRoot(layout, true).SetInputCapture(a.handleGlobalKeys)
+	return a
+}
+
+// Run blocks until the user quits or ctx is cancelled.
+func (a *App) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	a.ctx = ctx
+	a.reload(ctx)
+
+	go func() {
+		<-ctx.Done()
+		a.app.Stop()
+	}()
+	if a.refreshInterval > 0 {
+		go a.refreshLoop(ctx)
+	}
+
+	return a.app.Run()
+}
+
+func (a *App) refreshLoop(ctx context.Context) {
+	ticker := time.NewTicker(a.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			accounts, accErr := a.accSvc.List(ctx, false)
+			ops, opsErr := a.opSvc.List(ctx)
+			a.app.QueueUpdateDraw(func() {
+				a.apply(accounts, accErr, ops, opsErr)
+			})
+		}
+	}
+}
+
+// reload fetches fresh data synchronously. It must be called from the UI goroutine.
+func (a *App) reload(ctx context.Context) {
+	accounts, accErr := a.accSvc.List(ctx, false)
+	ops, opsErr := a.opSvc.List(ctx)
+	a.apply(accounts, accErr, ops, opsErr)
+}
+
+func (a *App) apply(accounts []dto.BankAccountDTO, accErr error, ops []dto.OperationDTO, opsErr error) {
+	switch {
+	case accErr != nil:
+		a.showError(accErr)
+		return
+	case opsErr != nil:
+		a.showError(opsErr)
+		return
+	}
+
+	a.accounts.setAccounts(accounts)
+	a.ops.setOperations(ops)
+	a.setStatus("[grey]Updated at " + time.Now().Format(time.TimeOnly))
+}
+
+func (a *App) handleGlobalKeys(event *tcell.EventKey) *tcell.EventKey {
+	if a.formShown {
+		return event
+	}
+	if event.Key() == tcell.KeyCtrlC || event.Rune() == 'q' && !a.ops.filterFocused() {
+		a.app.Stop()
+		return nil
+	}
+	return event
+}
+
+func (a *App) showAccounts() {
+	a.pages.SwitchToPage(pageAccounts)
+	a.app.SetFocus(a.accounts.table)
+}
+
+func (a *App) showOperations(acc dto.BankAccountDTO) {
+	a.ops.setAccount(acc)
+	a.pages.SwitchToPage(pageOperations)
+	a.app.SetFocus(a.ops.table)
+}
+
+func (a *App) showForm(form *tview.Form, title string) {
+	form.SetBorder(true).SetTitle(" " + title + " ")
+	form.SetCancelFunc(a.closeForm)
+
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(form, 13, 0, true).
+			AddItem(nil, 0, 1, false), 60, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	a.formShown = true
+	a.pages.AddPage(pageForm, modal, true, true)
+	a.app.SetFocus(form)
+}
+
+func (a *App) closeForm() {
+	a.formShown = false
+	a.pages.RemovePage(pageForm)
+
+	if name, _ := a.pages.GetFrontPage(); name == pageOperations {
+		a.app.SetFocus(a.ops.table)
+		return
+	}
+	a.app.SetFocus(a.accounts.table)
+}
+
+func (a *App) setStatus(text string) {
+	a.status.SetText(text)
+}
+
+func (a *App) showError(err error) {
+	a.setStatus("[red]Error: " + tview.Escape(err.Error()))
+}
+
+func shortID(id uuid.UUID) string {
+	return id.String()[:8]
+}
@@ -0,0 +1,101 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/rivo/tview"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/services"
+)
+
+func (a *App) showOperationForm(acc dto.BankAccountDTO, typ string) {
+	form := tview.NewForm()
+	form.AddTextView("Account", tview.Escape(acc.Name), 0, 1, false, false)
+	form.AddInputField("Amount", "", 20, tview.InputFieldInteger, nil)
+
+	form.AddButton("Apply", func() {
+		amount, err := parseAmount(form)
+		if err != nil {
+			a.showError(err)
+			return
+		}
+
+		_, err = a.opSvc.ApplyOperation(a.ctx, services.ApplyOperationRequest{
+			AccountID:     acc.ID,
+			Amount:        amount,
+			OperationType: typ,
+		})
+		if err != nil {
+			a.showError(err)
+			return
+		}
+
+		a.closeForm()
+		a.reload(a.ctx)
+		a.setStatus(fmt.Sprintf("[green]Applied %s of %d to %s", typ, amount, tview.Escape(acc.Name)))
+	})
+	form.AddButton("Cancel", a.closeForm)
+
+	a.showForm(form, "New "+typ)
+}
+
+func (a *App) showTransferForm(from dto.BankAccountDTO) {
+	var targets []dto.BankAccountDTO
+	var names []string
+	for _, acc := range a.accounts.accounts {
+		if acc.ID == from.ID {
+			continue
+		}
+		targets = append(targets, acc)
+		names = append(names, fmt.Sprintf("%s (%s)", acc.Name, shortID(acc.ID)))
+	}
+	if len(targets) == 0 {
+		a.showError(fmt.Errorf("there is no other account to transfer to"))
+		return
+	}
+
+	to := targets[0].ID
+	form := tview.NewForm()
+	form.AddTextView("From", tview.Escape(from.Name), 0, 1, false, false)
+	form.AddDropDown("To", names, 0, func(_ string, index int) {
+		if index >= 0 {
+			to = targets[index].ID
+		}
+	})
+	form.AddInputField("Amount", "", 20, tview.InputFieldInteger, nil)
+
+	form.AddButton("Transfer", func() {
+		amount, err := parseAmount(form)
+		if err != nil {
+			a.showError(err)
+			return
+		}
+
+		_, err = a.opSvc.Transfer(a.ctx, services.TransferRequest{
+			FromAccountID: from.ID,
+			ToAccountID:   to,
+			Amount:        amount,
+		})
+		if err != nil {
+			a.showError(err)
+			return
+		}
+
+		a.closeForm()
+		a.reload(a.ctx)
+		a.setStatus(fmt.Sprintf("[green]Transferred %d from %s", amount, tview.Escape(from.Name)))
+	})
+	form.AddButton("Cancel", a.closeForm)
+
+	a.showForm(form, "Transfer")
+}
+
+func parseAmount(form *tview.Form) (int64, error) {
+	text := form.GetFormItemByLabel("Amount").(*tview.InputField).GetText()
+	amount, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", text)
+	}
+	return amount, nil
+}
@@ -0,0 +1,196 @@
+package tui
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/google/uuid"
+	"github.com/rivo/tview"
+	"github.com/sunnyyssh/designing-software-cw1/internal/application/dto"
+)
+
+const operationsHelp = "[yellow]/[-] search  [yellow]f[-] type filter  [yellow]i[-] income  [yellow]o[-] outcome  [yellow]t[-] transfer  [yellow]Esc[-] back  [yellow]q[-] quit"
+
+var typeFilters = []string{"", "income", "outcome"}
+
+type operationsView struct {
+	app *App
+
+	root   *tview.Flex
+	filter *tview.InputField
+	table  *tview.Table
+
+	account    dto.BankAccountDTO
+	operations []dto.OperationDTO
+	// shown are the operations in the table rows, after the header.
+	shown      []dto.OperationDTO
+	typeFilter int
+}
+
+func newOperationsView(app *App) *operationsView {
+	v := &operationsView{
+		app:    app,
+		filter: tview.NewInputField().SetLabel("Search: "),
+		table:  tview.NewTable().SetSelectable(true, false).SetFixed(1, 0),
+	}
+	v.table.SetBorder(true)
+	v.table.SetInputCapture(v.handleKeys)
+
+	v.filter.SetChangedFunc(func(string) { v.render(true) })
+	v.filter.SetDoneFunc(func(tcell.Key) { app.app.SetFocus(v.table) })
+
+	v.root = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(v.filter, 1, 0, false).
+		AddItem(v.table, 0, 1, true).
+		AddItem(tview.NewTextView().SetDynamicColors(true).SetText(operationsHelp), 1, 0, false)
+	return v
+}
+
+func (v *operationsView) setAccount(acc dto.BankAccountDTO) {
+	v.account = acc
+	v.filter.SetText("")
+	v.typeFilter = 0
+	v.render(true)
+}
+
+func (v *operationsView) setOperations(ops []dto.OperationDTO) {
+	v.operations = ops
+	for _, acc := range v.app.accounts.accounts {
+		if acc.ID == v.account.ID {
+			v.account = acc
+		}
+	}
+	v.render(false)
+}
+
+func (v *operationsView) filterFocused() bool {
+	return v.filter.HasFocus()
+}
+
+// render fills the table. Unless reset, e.g. when a filter changes, the selected operation
+// stays selected and the rows above it are scrolled the same, so a refresh doesn't lose the place.
+func (v *operationsView) render(reset bool) {
+	title := " " + tview.Escape(v.account.Name) + " | balance " + strconv.FormatInt(v.account.Balance, 10)
+	if typ := typeFilters[v.typeFilter]; typ != "" {
+		title += " | " + typ + " only"
+	}
+	v.table.SetTitle(title + " ")
+
+	row, _ := v.table.GetSelection()
+	offset, _ := v.table.GetOffset()
+	var selectedID uuid.UUID
+	if row >= 1 && row <= len(v.shown) {
+		selectedID = v.shown[row-1].ID
+	}
+
+	v.table.Clear()
+	for col, name := range []string{"Time", "Type", "Amount", "Category", "Description"} {
+		v.table.SetCell(0, col, tview.NewTableCell(name).
+			SetTextColor(tcell.ColorYellow).
+			SetSelectable(false))
+	}
+
+	v.shown = v.visible()
+	for i, op := range v.shown {
+		color := tcell.ColorGreen
+		if op.Type == "outcome" {
+			color = tcell.ColorRed
+		}
+		category := ""
+		if op.CategoryID != nil {
+			category = shortID(*op.CategoryID)
+		}
+		v.table.SetCell(i+1, 0, tview.NewTableCell(op.Time.Local().Format(time.DateTime)))
+		v.table.SetCell(i+1, 1, tview.NewTableCell(op.Type).SetTextColor(color))
+		v.table.SetCell(i+1, 2, tview.NewTableCell(strconv.FormatInt(op.Amount, 10)).SetAlign(tview.AlignRight))
+		v.table.SetCell(i+1, 3, tview.NewTableCell(category))
+		v.table.SetCell(i+1, 4, tview.NewTableCell(tview.Escape(op.Description)).SetExpansion(1))
+	}
+
+	if reset {
+		v.table.Select(1, 0)
+		v.table.ScrollToBeginning()
+		return
+	}
+	newRow := row
+	for i, op := range v.shown {
+		if op.ID == selectedID {
+			newRow = i + 1
+		}
+	}
+	newRow = min(max(newRow, 1), max(len(v.shown), 1))
+	v.table.SetOffset(max(offset+newRow-row, 0), 0)
+	v.table.Select(newRow, 0)
+}
+
+// visible returns operations of the current account matching the search and type filters, newest first.
+func (v *operationsView) visible() []dto.OperationDTO {
+	query := strings.ToLower(strings.TrimSpace(v.filter.GetText()))
+	typ := typeFilters[v.typeFilter]
+
+	var res []dto.OperationDTO
+	for _, op := range v.operations {
+		if op.AccountID != v.account.ID {
+			continue
+		}
+		if typ != "" && op.Type != typ {
+			continue
+		}
+		if query != "" && !matches(op, query) {
+			continue
+		}
+		res = append(res, op)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Time.After(res[j].Time)
+	})
+	return res
+}
+
+func matches(op dto.OperationDTO, query string) bool {
+	fields := []string{
+		op.Type,
+		strconv.FormatInt(op.Amount, 10),
+		strings.ToLower(op.Description),
+		op.ID.String(),
+	}
+	if op.CategoryID != nil {
+		fields = append(fields, op.CategoryID.String())
+	}
+	for _, f := range fields {
+		if strings.Contains(f, query) {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *operationsView) handleKeys(event *tcell.EventKey) *tcell.EventKey {
+	if event.Key() == tcell.KeyEscape {
+		v.app.showAccounts()
+		return nil
+	}
+
+	switch event.Rune() {
+	case '/':
+		v.app.app.SetFocus(v.filter)
+	case 'f':
+		v.typeFilter = (v.typeFilter + 1) % len(typeFilters)
+		v.render(true)
+	case 'r':
+		v.app.reload(v.app.ctx)
+	case 'i':
+		v.app.showOperationForm(v.account, "income")
+	case 'o':
+		v.app.showOperationForm(v.account, "outcome")
+	case 't':
+		v.app.showTransferForm(v.account)
+	default:
+		return event
+	}
+	return nil
+}
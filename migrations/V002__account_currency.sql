@@ -0,0 +1,2 @@
+ALTER TABLE bank_accounts ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'RUB';
+ALTER TABLE bank_accounts ALTER COLUMN currency DROP DEFAULT;
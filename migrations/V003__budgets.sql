@@ -0,0 +1,7 @@
+CREATE TABLE budgets (
+    id           UUID PRIMARY KEY,
+    category_id  UUID NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+    period       VARCHAR(255) NOT NULL,
+    limit_amount BIGINT NOT NULL,
+    UNIQUE (category_id, period)
+);
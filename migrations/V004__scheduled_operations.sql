@@ -0,0 +1,18 @@
+CREATE TABLE scheduled_operations (
+    id           UUID PRIMARY KEY,
+    account_id   UUID NOT NULL REFERENCES bank_accounts (id) ON DELETE CASCADE,
+    type         VARCHAR(255) NOT NULL,
+    amount       BIGINT NOT NULL,
+    description  TEXT NOT NULL,
+    category_id  UUID REFERENCES categories (id) ON DELETE SET NULL,
+    recurrence   TEXT NOT NULL,
+    start_at     TIMESTAMP NOT NULL
+);
+
+-- One row per materialized occurrence, so `schedule run` never applies it twice.
+CREATE TABLE scheduled_occurrences (
+    schedule_id  UUID NOT NULL REFERENCES scheduled_operations (id) ON DELETE CASCADE,
+    occurs_at    TIMESTAMP NOT NULL,
+    operation_id UUID NOT NULL,
+    PRIMARY KEY (schedule_id, occurs_at)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE bank_accounts ADD COLUMN type VARCHAR(255) NOT NULL DEFAULT 'debit';
+ALTER TABLE bank_accounts ADD COLUMN overdraft_limit BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE categories ADD COLUMN parent_id UUID REFERENCES categories (id) ON DELETE SET NULL;
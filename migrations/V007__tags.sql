@@ -0,0 +1,12 @@
+CREATE TABLE tags (
+    id   UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE
+);
+
+CREATE TABLE operation_tags (
+    operation_id UUID NOT NULL REFERENCES operations (id) ON DELETE CASCADE,
+    tag_id       UUID NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (operation_id, tag_id)
+);
+
+CREATE INDEX operation_tags_tag_id_idx ON operation_tags (tag_id);
@@ -0,0 +1,8 @@
+CREATE TABLE operation_splits (
+    operation_id UUID NOT NULL REFERENCES operations (id) ON DELETE CASCADE,
+    category_id  UUID REFERENCES categories (id) ON DELETE SET NULL,
+    amount       BIGINT NOT NULL,
+    UNIQUE (operation_id, category_id)
+);
+
+CREATE INDEX operation_splits_category_id_idx ON operation_splits (category_id);
@@ -0,0 +1,13 @@
+CREATE TABLE audit_log (
+    id          UUID PRIMARY KEY,
+    actor       VARCHAR(255) NOT NULL,
+    action      VARCHAR(255) NOT NULL,
+    entity_type VARCHAR(255) NOT NULL,
+    entity_id   UUID NOT NULL,
+    before      JSONB,
+    after       JSONB,
+    time        TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX audit_log_entity_idx ON audit_log (entity_type, entity_id, time);
+CREATE INDEX audit_log_time_idx ON audit_log (time);
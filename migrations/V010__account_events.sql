@@ -0,0 +1,28 @@
+-- Append-only store of bank account events, bank_accounts is kept as their projection.
+-- account_id has no foreign key: events outlive deleted accounts.
+CREATE TABLE account_events (
+    id         UUID PRIMARY KEY,
+    account_id UUID NOT NULL,
+    version    BIGINT NOT NULL,
+    type       VARCHAR(255) NOT NULL,
+    time       TIMESTAMP WITH TIME ZONE NOT NULL,
+    data       JSONB NOT NULL,
+    UNIQUE (account_id, version)
+);
+
+-- Accounts created before the event store start from a snapshot of their current state.
+INSERT INTO account_events (id, account_id, version, type, time, data)
+SELECT gen_random_uuid(), id, 1, 'AccountCreated', now(),
+       jsonb_build_object(
+           'name', name,
+           'account_type', type,
+           'currency', currency,
+           'overdraft_limit', overdraft_limit,
+           'balance', balance
+       )
+FROM bank_accounts;
+
+INSERT INTO account_events (id, account_id, version, type, time, data)
+SELECT gen_random_uuid(), id, 2, 'AccountBlocked', now(), '{}'::jsonb
+FROM bank_accounts
+WHERE blocked;
@@ -0,0 +1,12 @@
+CREATE TABLE outbox (
+    id              UUID PRIMARY KEY,
+    type            VARCHAR(255) NOT NULL,
+    payload         JSONB NOT NULL,
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL,
+    status          VARCHAR(255) NOT NULL,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_error      TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX outbox_pending_idx ON outbox (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,43 @@
+-- Notify bankcli watchers about new operations and changed accounts.
+-- Payloads are kept small, NOTIFY payloads are limited to 8000 bytes.
+CREATE FUNCTION notify_operation_created() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('bankcli_changes', json_build_object(
+        'kind', 'operation',
+        'id', NEW.id,
+        'account_id', NEW.account_id,
+        'type', NEW.type,
+        'amount', NEW.amount,
+        -- operations.time has no zone, pgx reads it as UTC.
+        'time', NEW.time AT TIME ZONE 'UTC',
+        'description', NEW.description,
+        'category_id', NEW.category_id
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER operations_notify
+    AFTER INSERT ON operations
+    FOR EACH ROW EXECUTE FUNCTION notify_operation_created();
+
+CREATE FUNCTION notify_account_changed() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('bankcli_changes', json_build_object(
+        'kind', 'account',
+        'id', NEW.id,
+        'account_id', NEW.id,
+        'name', NEW.name,
+        'type', NEW.type,
+        'currency', NEW.currency,
+        'balance', NEW.balance,
+        'overdraft_limit', NEW.overdraft_limit,
+        'blocked', NEW.blocked
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER bank_accounts_notify
+    AFTER UPDATE ON bank_accounts
+    FOR EACH ROW WHEN (OLD IS DISTINCT FROM NEW) EXECUTE FUNCTION notify_account_changed();
@@ -0,0 +1,6 @@
+CREATE TABLE idempotency_keys (
+    key         VARCHAR(255) PRIMARY KEY,
+    fingerprint VARCHAR(255) NOT NULL,
+    response    JSONB,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL
+);
@@ -0,0 +1,3 @@
+-- Deleted accounts and categories are kept, so that past operations still resolve them.
+ALTER TABLE bank_accounts ADD COLUMN closed_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE categories ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,68 @@
+-- Referential integrity, value checks and time zones.
+
+-- Operations of accounts deleted before soft delete existed point nowhere.
+-- Bring those accounts back as closed ones, restoring what their events still know.
+INSERT INTO bank_accounts (id, name, type, currency, balance, overdraft_limit, blocked, closed_at)
+SELECT o.account_id,
+       COALESCE(created.data ->> 'name', 'Deleted account'),
+       COALESCE(created.data ->> 'account_type', 'debit'),
+       COALESCE(created.data ->> 'currency', 'XXX'),
+       0, 0, FALSE,
+       COALESCE((SELECT max(d.time) FROM account_events d
+                 WHERE d.account_id = o.account_id AND d.type = 'AccountDeleted'), now())
+FROM (SELECT account_id FROM operations UNION SELECT account_id FROM account_events) o
+LEFT JOIN account_events created ON created.account_id = o.account_id AND created.type = 'AccountCreated'
+WHERE NOT EXISTS (SELECT 1 FROM bank_accounts a WHERE a.id = o.account_id);
+
+-- Accounts are closed instead of deleted, so deleting one with operations is a bug.
+ALTER TABLE operations
+    ADD CONSTRAINT operations_account_id_fkey
+    FOREIGN KEY (account_id) REFERENCES bank_accounts (id) ON DELETE RESTRICT;
+ALTER TABLE account_events
+    ADD CONSTRAINT account_events_account_id_fkey
+    FOREIGN KEY (account_id) REFERENCES bank_accounts (id) ON DELETE CASCADE;
+
+ALTER TABLE bank_accounts
+    ADD CONSTRAINT bank_accounts_type_check CHECK (type IN ('debit', 'credit', 'savings')),
+    ADD CONSTRAINT bank_accounts_overdraft_limit_check CHECK (overdraft_limit >= 0),
+    ADD CONSTRAINT bank_accounts_balance_check CHECK (balance >= -overdraft_limit);
+ALTER TABLE categories
+    ADD CONSTRAINT categories_type_check CHECK (type IN ('income', 'outcome'));
+ALTER TABLE operations
+    ADD CONSTRAINT operations_type_check CHECK (type IN ('income', 'outcome')),
+    ADD CONSTRAINT operations_amount_check CHECK (amount > 0);
+ALTER TABLE operation_splits
+    ADD CONSTRAINT operation_splits_amount_check CHECK (amount > 0);
+ALTER TABLE scheduled_operations
+    ADD CONSTRAINT scheduled_operations_type_check CHECK (type IN ('income', 'outcome')),
+    ADD CONSTRAINT scheduled_operations_amount_check CHECK (amount > 0);
+ALTER TABLE budgets
+    ADD CONSTRAINT budgets_period_check CHECK (period IN ('monthly', 'weekly')),
+    ADD CONSTRAINT budgets_limit_amount_check CHECK (limit_amount > 0);
+
+-- pgx wrote the local wall-clock time of the CLI into these columns.
+-- Run the migration with TimeZone set to the zone the CLI ran in.
+ALTER TABLE operations ALTER COLUMN time TYPE TIMESTAMP WITH TIME ZONE;
+ALTER TABLE scheduled_operations ALTER COLUMN start_at TYPE TIMESTAMP WITH TIME ZONE;
+ALTER TABLE scheduled_occurrences ALTER COLUMN occurs_at TYPE TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX operations_account_id_time_idx ON operations (account_id, time);
+CREATE INDEX operations_time_idx ON operations (time);
+CREATE INDEX operations_category_id_idx ON operations (category_id);
+
+-- operations.time has a zone now, it goes into the payload as is.
+CREATE OR REPLACE FUNCTION notify_operation_created() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('bankcli_changes', json_build_object(
+        'kind', 'operation',
+        'id', NEW.id,
+        'account_id', NEW.account_id,
+        'type', NEW.type,
+        'amount', NEW.amount,
+        'time', NEW.time,
+        'description', NEW.description,
+        'category_id', NEW.category_id
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
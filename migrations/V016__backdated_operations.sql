@@ -0,0 +1,21 @@
+-- Operations may be entered with an earlier time, but not before their account existed.
+ALTER TABLE bank_accounts ADD COLUMN created_at TIMESTAMP WITH TIME ZONE;
+
+-- The creation event of accounts older than the event store is the migration time,
+-- so their first operation may be earlier.
+UPDATE bank_accounts a
+SET created_at = LEAST(
+    (SELECT min(e.time) FROM account_events e WHERE e.account_id = a.id AND e.type = 'AccountCreated'),
+    (SELECT min(o.time) FROM operations o WHERE o.account_id = a.id),
+    now()
+);
+
+ALTER TABLE bank_accounts
+    ALTER COLUMN created_at SET DEFAULT now(),
+    ALTER COLUMN created_at SET NOT NULL;
+
+-- Balance as of a time follows the operation time, which now differs from the time it was applied.
+UPDATE account_events e
+SET data = e.data || jsonb_build_object('operation_time', o.time)
+FROM operations o
+WHERE e.type = 'OperationApplied' AND o.id = (e.data ->> 'operation_id')::uuid;
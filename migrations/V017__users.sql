@@ -0,0 +1,17 @@
+-- Users authenticate with API tokens, accounts are visible only to their members.
+CREATE TABLE users (
+    id         UUID PRIMARY KEY,
+    name       VARCHAR(255) NOT NULL UNIQUE,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Existing accounts have no members, the first user created becomes their owner.
+CREATE TABLE account_members (
+    account_id UUID NOT NULL REFERENCES bank_accounts (id) ON DELETE CASCADE,
+    user_id    UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role       VARCHAR(16) NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+    PRIMARY KEY (account_id, user_id)
+);
+
+CREATE INDEX account_members_user_id_idx ON account_members (user_id);
@@ -0,0 +1,74 @@
+-- Households sharing the database are isolated by tenant_id with row-level security.
+-- bankcli sets bankcli.tenant at the start of every transaction, rows of other tenants
+-- are invisible and can't be written. Existing data goes to the 'default' tenant.
+--
+-- Superusers and roles with BYPASSRLS ignore the policies, connect bankcli as a plain role.
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY[
+        'bank_accounts', 'categories', 'operations', 'budgets', 'scheduled_operations',
+        'scheduled_occurrences', 'tags', 'operation_tags', 'operation_splits', 'audit_log',
+        'account_events', 'outbox', 'idempotency_keys', 'users', 'account_members'
+    ] LOOP
+        EXECUTE format('ALTER TABLE %I ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT ''default''', t);
+        -- Inserts take the tenant of the transaction, failing if none is set.
+        EXECUTE format('ALTER TABLE %I ALTER COLUMN tenant_id SET DEFAULT current_setting(''bankcli.tenant'')', t);
+        EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t);
+        -- Without FORCE the owner of the tables, usually the role bankcli connects as, bypasses the policy.
+        EXECUTE format('ALTER TABLE %I FORCE ROW LEVEL SECURITY', t);
+        EXECUTE format(
+            'CREATE POLICY tenant_isolation ON %I '
+            'USING (tenant_id = current_setting(''bankcli.tenant'', true)) '
+            'WITH CHECK (tenant_id = current_setting(''bankcli.tenant'', true))',
+            t
+        );
+        EXECUTE format('CREATE INDEX %I ON %I (tenant_id)', t || '_tenant_id_idx', t);
+    END LOOP;
+END;
+$$;
+
+-- Names and keys are unique per tenant.
+ALTER TABLE tags DROP CONSTRAINT tags_name_key;
+ALTER TABLE tags ADD CONSTRAINT tags_tenant_id_name_key UNIQUE (tenant_id, name);
+ALTER TABLE users DROP CONSTRAINT users_name_key;
+ALTER TABLE users ADD CONSTRAINT users_tenant_id_name_key UNIQUE (tenant_id, name);
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (tenant_id, key);
+
+-- Watchers get notifications of every tenant, they skip ones of other tenants.
+CREATE OR REPLACE FUNCTION notify_operation_created() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('bankcli_changes', json_build_object(
+        'kind', 'operation',
+        'tenant_id', NEW.tenant_id,
+        'id', NEW.id,
+        'account_id', NEW.account_id,
+        'type', NEW.type,
+        'amount', NEW.amount,
+        'time', NEW.time,
+        'description', NEW.description,
+        'category_id', NEW.category_id
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION notify_account_changed() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('bankcli_changes', json_build_object(
+        'kind', 'account',
+        'tenant_id', NEW.tenant_id,
+        'id', NEW.id,
+        'account_id', NEW.id,
+        'name', NEW.name,
+        'type', NEW.type,
+        'currency', NEW.currency,
+        'balance', NEW.balance,
+        'overdraft_limit', NEW.overdraft_limit,
+        'blocked', NEW.blocked
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,23 @@
+-- NOTIFY isn't subject to row-level security and every session listening on the channel
+-- gets every payload, so payloads carry only ids. Watchers read the rows again in their tenant.
+CREATE OR REPLACE FUNCTION notify_operation_created() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('bankcli_changes', json_build_object(
+        'kind', 'operation',
+        'tenant_id', NEW.tenant_id,
+        'id', NEW.id
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION notify_account_changed() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('bankcli_changes', json_build_object(
+        'kind', 'account',
+        'tenant_id', NEW.tenant_id,
+        'id', NEW.id
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,6 @@
+-- Keys are per user, requests of different users with the same key don't collide.
+-- Keys saved before don't belong to any user and are never matched again.
+ALTER TABLE idempotency_keys ADD COLUMN user_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000';
+ALTER TABLE idempotency_keys ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (tenant_id, user_id, key);